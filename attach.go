@@ -0,0 +1,453 @@
+package sqlbless
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hymkor/sqlbless/dialect"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/internal/sqllex"
+)
+
+var (
+	ErrInvalidAttachSyntax = errors.New("invalid syntax: expected 'ATTACH CSV file AS name [FORMAT csv|tsv]'")
+	ErrUnknownAttachFormat = errors.New("unknown format: expected csv or tsv")
+	ErrScratchUnavailable  = errors.New("the sqlite3 driver for the scratch database is not linked")
+	ErrScratchNameInUse    = errors.New("a table of the connection has the name")
+)
+
+type attachSpec struct {
+	file   string
+	name   string
+	format string
+}
+
+// isAttachCSV reports whether the arguments of ATTACH are the ones of
+// SQL-Bless. The others such as `ATTACH DATABASE` of SQLite3 are sent
+// to the server.
+func isAttachCSV(arg string) bool {
+	word, _ := misc.CutField(arg)
+	return strings.EqualFold(word, "CSV")
+}
+
+// parseAttachArgs parses `CSV file AS name [FORMAT csv|tsv]`.
+func parseAttachArgs(arg string, comma byte) (*attachSpec, error) {
+	spec := &attachSpec{}
+	csv, arg := misc.CutField(arg)
+	if !strings.EqualFold(csv, "CSV") {
+		return nil, ErrInvalidAttachSyntax
+	}
+	spec.file, arg = cutWord(arg)
+	as, arg := misc.CutField(arg)
+	spec.name, arg = misc.CutField(arg)
+	if spec.file == "" || !strings.EqualFold(as, "AS") || spec.name == "" {
+		return nil, ErrInvalidAttachSyntax
+	}
+	if word, arg := misc.CutField(arg); word != "" {
+		format, rest := misc.CutField(arg)
+		if !strings.EqualFold(word, "FORMAT") || strings.TrimSpace(rest) != "" {
+			return nil, ErrInvalidAttachSyntax
+		}
+		switch spec.format = strings.ToLower(format); spec.format {
+		case "csv", "tsv":
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAttachFormat, format)
+		}
+		return spec, nil
+	}
+	switch format := fileFormatOf(spec.file); format {
+	case "csv", "tsv":
+		spec.format = format
+	default:
+		spec.format = "csv"
+		if comma == '\t' {
+			spec.format = "tsv"
+		}
+	}
+	return spec, nil
+}
+
+var rxNonWord = regexp.MustCompile(`\W+`)
+
+// attachNameOf returns the table name for the file given with -csv of
+// sqlite3bless: the base name without the extension.
+func attachNameOf(fname string) string {
+	name := filepath.Base(fname)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if name = rxNonWord.ReplaceAllString(name, "_"); name == "" {
+		return "T"
+	}
+	return name
+}
+
+// scratchDB is the in-memory SQLite3 database of the session. The
+// tables attached from files are kept apart from the connected database
+// and statements for them are executed by its own session.
+type scratchDB struct {
+	db     *sql.DB
+	ss     *session
+	tables map[string]struct{}
+}
+
+// load replaces the table of the scratch database with the one created
+// by ddl and fills it by insert in the transaction. The name is registered
+// so that statements for the table are executed on the scratch database.
+func (sc *scratchDB) load(ctx context.Context, name, ddl string, insert func(*sql.Tx) (int64, error)) error {
+	conn := sc.ss.conn
+	if _, err := conn.ExecContext(ctx, "DROP TABLE IF EXISTS "+name); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, ddl); err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("BeginTx: %[1]w (%[1]T)", err)
+	}
+	if _, err := insert(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	sc.tables[strings.ToUpper(name)] = struct{}{}
+	return nil
+}
+
+func (sc *scratchDB) Close() {
+	if sc.ss.tx != nil {
+		sc.ss.tx.Rollback()
+	}
+	sc.ss.conn.Close()
+	sc.db.Close()
+}
+
+// owns reports whether the statement is for a table of the scratch
+// database.
+func (sc *scratchDB) owns(cmd, arg, query string, syntax dialect.Syntax) bool {
+	var table string
+	switch strings.ToUpper(cmd) {
+	case "EDIT", "DESC", "\\D", "UPDATE":
+		table, _ = misc.CutField(arg)
+	case "INSERT":
+		_, arg = misc.CutField(arg)
+		table, _ = misc.CutField(arg)
+		table, _, _ = strings.Cut(table, "(")
+	case "SELECT", "DELETE":
+		table = sqllex.FirstTable(query, syntax)
+	default:
+		return false
+	}
+	_, ok := sc.tables[strings.ToUpper(table)]
+	return ok
+}
+
+func (ss *session) isSQLite() bool {
+	d, ok := dialect.Find("sqlite3")
+	return ok && d == ss.Dialect
+}
+
+// checkScratchName refuses the name of a table of the connection for the
+// scratch database, because the statements for the name would be executed
+// on the scratch database instead of the connection.
+func (ss *session) checkScratchName(ctx context.Context, name string) error {
+	var conn dialect.CanQuery = ss.conn
+	if ss.tx != nil {
+		conn = ss.tx
+	}
+	tables, err := ss.Dialect.FetchTables(ctx, conn)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if strings.EqualFold(table, name) {
+			return fmt.Errorf("%s: %w", name, ErrScratchNameInUse)
+		}
+	}
+	return nil
+}
+
+// scratchSession returns the session of the scratch database to create
+// the tables of ATTACH CSV and STASH. It is opened at the first call.
+func (ss *session) scratchSession(ctx context.Context) (*session, error) {
+	if ss.scratch != nil {
+		return ss.scratch.ss, nil
+	}
+	info, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrScratchUnavailable, err.Error())
+	}
+	db, err := sql.Open(info.Driver, info.DataSource)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open: %[1]w (%[1]T)", err)
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("db.Conn: %w", err)
+	}
+	ss.scratch = &scratchDB{
+		db: db,
+		ss: &session{
+			Config:  ss.Config,
+			Dialect: info.Dialect,
+			conn:    conn,
+			history: ss.history,
+		},
+		tables: map[string]struct{}{},
+	}
+	return ss.scratch.ss, nil
+}
+
+// executeInScratch executes the statement for the tables of the scratch
+// database. Its output follows the session and the changes are
+// committed at once.
+func (ss *session) executeInScratch(ctx context.Context, query string, commandIn commandIn) error {
+	sc := ss.scratch.ss
+	sc.spool = ss.spool
+	sc.stdOut, sc.termOut = ss.stdOut, ss.termOut
+	sc.stdErr, sc.termErr = ss.stdErr, ss.termErr
+	sc.format, sc.outFormat, sc.resultOut = ss.format, ss.outFormat, ss.resultOut
+	sc.bindVars = ss.bindVars
+	sc.deadline = ss.deadline
+
+	// The transaction begun here suppresses the message of beginTx.
+	var err error
+	sc.tx, err = sc.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("BeginTx: %[1]w (%[1]T)", err)
+	}
+	_, err = sc.execute(ctx, query, commandIn)
+	if sc.tx != nil {
+		if err != nil {
+			sc.tx.Rollback()
+		} else {
+			err = sc.tx.Commit()
+		}
+		sc.tx = nil
+	}
+	return err
+}
+
+// columnKind is the type of a column of an attached file inferred from
+// its values.
+type columnKind int
+
+const (
+	kindUnknown columnKind = iota
+	kindInteger
+	kindReal
+	kindText
+)
+
+func (k columnKind) String() string {
+	switch k {
+	case kindInteger:
+		return "INTEGER"
+	case kindReal:
+		return "REAL"
+	}
+	return "TEXT"
+}
+
+// kindOfValue returns the narrowest type of the value. Numbers with
+// leading zeros such as codes, NaN and infinities are texts.
+func kindOfValue(s string) columnKind {
+	if len(s) > 1 && s[0] == '0' && s[1] != '.' {
+		return kindText
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return kindInteger
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return kindReal
+	}
+	return kindText
+}
+
+// inferKinds returns the type of each column which can hold all values
+// of it. Empty values and NULL do not affect the type.
+func inferKinds(records [][]string, width int, null string) []columnKind {
+	kinds := make([]columnKind, width)
+	for _, rec := range records {
+		for i, field := range rec {
+			if field == "" || field == null || kinds[i] == kindText {
+				continue
+			}
+			if k := kindOfValue(field); k > kinds[i] {
+				kinds[i] = k
+			}
+		}
+	}
+	return kinds
+}
+
+// attachValue converts the field to the value for the column.
+func attachValue(field string, kind columnKind, null string) any {
+	if field == null || (field == "" && kind != kindText) {
+		return nil
+	}
+	switch kind {
+	case kindInteger:
+		v, _ := strconv.ParseInt(field, 10, 64)
+		return v
+	case kindReal:
+		v, _ := strconv.ParseFloat(field, 64)
+		return v
+	}
+	return field
+}
+
+func readAttachFile(spec *attachSpec) ([]string, [][]string, error) {
+	fd, err := os.Open(spec.file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer fd.Close()
+
+	comma := ','
+	if spec.format == "tsv" {
+		comma = '\t'
+	}
+	reader := newCsvImportReader(fd, comma)
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = errors.New("no header line")
+		}
+		return nil, nil, fmt.Errorf("%s: %w", spec.file, err)
+	}
+	var records [][]string
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", spec.file, err)
+		}
+		if len(rec.fields) > len(header.fields) {
+			return nil, nil, fmt.Errorf("%s: line %d: %w: expected %d, but got %d",
+				spec.file, rec.line, ErrFieldCountMismatch, len(header.fields), len(rec.fields))
+		}
+		records = append(records, rec.fields)
+	}
+	columns := make([]string, len(header.fields))
+	for i, name := range header.fields {
+		if columns[i] = strings.TrimSpace(name); columns[i] == "" {
+			columns[i] = fmt.Sprintf("COL%d", i+1)
+		}
+	}
+	return columns, records, nil
+}
+
+// insertAttached inserts the records of the attached file in the transaction.
+func (ss *session) insertAttached(ctx context.Context, tx *sql.Tx, table string, columns []string, kinds []columnKind, records [][]string) error {
+	stmt, err := tx.PrepareContext(ctx, ss.insertSQL(table, columns, 1))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	holder := ss.Dialect.PlaceHolder
+	for _, rec := range records {
+		for i, kind := range kinds {
+			if i < len(rec) {
+				holder.Make(attachValue(rec[i], kind, ss.Null))
+			} else {
+				holder.Make(nil)
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, holder.Values()...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachTemp creates the table as a temporary table of the connection on
+// SQLite3, so that it can be joined with the other tables. The rows are
+// inserted in the transaction of the session when it is open.
+func (ss *session) attachTemp(ctx context.Context, name, definitions string, columns []string, kinds []columnKind, records [][]string) error {
+	if err := ss.execDDL(ctx, "DROP TABLE IF EXISTS temp."+name); err != nil {
+		return err
+	}
+	ddl := fmt.Sprintf("CREATE TEMP TABLE %s (\n    %s\n)", name, definitions)
+	if err := ss.execDDL(ctx, ddl); err != nil {
+		return err
+	}
+	isNewTx := (ss.tx == nil)
+	if err := ss.beginTx(io.Discard); err != nil {
+		return err
+	}
+	err := ss.insertAttached(ctx, ss.tx, name, columns, kinds, records)
+	if !isNewTx {
+		return err
+	}
+	if err != nil {
+		ss.tx.Rollback()
+	} else {
+		err = ss.tx.Commit()
+	}
+	ss.tx = nil
+	return err
+}
+
+// attachCSV creates the table from the file and returns the number of rows.
+// On SQLite3, it is a temporary table of the connection. On the other
+// databases and read-only connections, it is a table of the scratch database.
+func (ss *session) attachCSV(ctx context.Context, spec *attachSpec) (int, error) {
+	columns, records, err := readAttachFile(spec)
+	if err != nil {
+		return 0, err
+	}
+	kinds := inferKinds(records, len(columns), ss.Null)
+	definitions := func(d *dialect.Entry) string {
+		lines := make([]string, len(columns))
+		for i, name := range columns {
+			lines[i] = d.EncloseIdentifierIfNeed(name) + " " + kinds[i].String()
+		}
+		return strings.Join(lines, ",\n    ")
+	}
+	if ss.isSQLite() && (ss.current == nil || !ss.current.readOnly) {
+		err := ss.attachTemp(ctx, spec.name, definitions(ss.Dialect), columns, kinds, records)
+		return len(records), err
+	}
+	if err := ss.checkScratchName(ctx, spec.name); err != nil {
+		return 0, err
+	}
+	target, err := ss.scratchSession(ctx)
+	if err != nil {
+		return 0, err
+	}
+	ddl := fmt.Sprintf("CREATE TABLE %s (\n    %s\n)", spec.name, definitions(target.Dialect))
+	err = ss.scratch.load(ctx, spec.name, ddl, func(tx *sql.Tx) (int64, error) {
+		return int64(len(records)), target.insertAttached(ctx, tx, spec.name, columns, kinds, records)
+	})
+	return len(records), err
+}
+
+// doAttach loads the CSV or TSV file into a table of the in-memory
+// SQLite3 database so that it can be queried and edited.
+func doAttach(ctx context.Context, ss *session, arg string) error {
+	spec, err := parseAttachArgs(arg, ss.comma())
+	if err != nil {
+		return err
+	}
+	count, err := ss.attachCSV(ctx, spec)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ss.stdErr, "%d row(s) attached as %s\n", count, spec.name)
+	return nil
+}
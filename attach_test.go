@@ -0,0 +1,190 @@
+package sqlbless
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func TestParseAttachArgs(t *testing.T) {
+	for _, c := range []struct {
+		arg    string
+		expect *attachSpec
+		err    error
+	}{
+		{
+			arg:    "CSV data.csv AS D",
+			expect: &attachSpec{file: "data.csv", name: "D", format: "csv"},
+		},
+		{
+			arg:    "csv 'my data.txt' as D format TSV",
+			expect: &attachSpec{file: "my data.txt", name: "D", format: "tsv"},
+		},
+		{
+			arg:    "CSV data.tsv AS D",
+			expect: &attachSpec{file: "data.tsv", name: "D", format: "tsv"},
+		},
+		{arg: "DATABASE 'a.db' AS A", err: ErrInvalidAttachSyntax},
+		{arg: "CSV data.csv D", err: ErrInvalidAttachSyntax},
+		{arg: "CSV data.csv AS", err: ErrInvalidAttachSyntax},
+		{arg: "CSV data.csv AS D HEADER", err: ErrInvalidAttachSyntax},
+		{arg: "CSV data.csv AS D FORMAT jsonl", err: ErrUnknownAttachFormat},
+	} {
+		spec, err := parseAttachArgs(c.arg, ',')
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Fatalf("%s: expect %v, but %v", c.arg, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", c.arg, err.Error())
+		}
+		if !reflect.DeepEqual(spec, c.expect) {
+			t.Fatalf("%s: expect %#v, but %#v", c.arg, c.expect, spec)
+		}
+	}
+}
+
+func TestInferKinds(t *testing.T) {
+	records := [][]string{
+		{"1", "1.5", "007", "x", "", "1", "1", "1", "1"},
+		{"2", "2", "8", "", "", "1.0", "NaN", "Inf", "-infinity"},
+	}
+	expect := []columnKind{kindInteger, kindReal, kindText, kindText, kindUnknown, kindReal, kindText, kindText, kindText}
+	if kinds := inferKinds(records, 9, "␀"); !reflect.DeepEqual(kinds, expect) {
+		t.Fatalf("expect %v, but %v", expect, kinds)
+	}
+}
+
+func testAttach(t *testing.T, d *dialect.Entry) {
+	t.Helper()
+	restoreColor := disableColor()
+	defer restoreColor()
+
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			ATTACH CSV '` + filepath.Join(dir, "data.csv") + `' AS D;
+			UPDATE D SET NAME = 'qux' WHERE ID = 3;
+			SPOOL ` + spool + `;
+			SELECT ID + 1, NAME, PRICE FROM D WHERE CODE = '007' OR PRICE IS NULL ORDER BY ID;
+			SPOOL OFF;
+			COMMIT;`,
+		"data.csv": "ID,NAME,CODE,PRICE\n1,foo,007,1.5\n2,bar,008,2\n3,,009,\n",
+	})
+	info, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cfg := New()
+	cfg.Script = filepath.Join(dir, "main.sql")
+	cfg.Null = "null"
+	if err := cfg.Run(info.Driver, info.DataSource, d); err != nil {
+		t.Fatal(err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	expect := "2,foo,1.5|4,qux,null"
+	if got := strings.Join(lines, "|"); !strings.HasSuffix(got, expect) {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+}
+
+func TestAttach(t *testing.T) {
+	d := sqliteDialectForTest(t)
+	// The tables are temporary ones of the connection on SQLite3.
+	testAttach(t, d)
+
+	// The statements for the attached table are executed on the scratch
+	// database even when the connection is not SQLite3.
+	other := *d
+	testAttach(t, &other)
+}
+
+func TestAttachJoinsTablesOfSQLite(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(csvPath, []byte("ID,NAME\n1,foo\n"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	records := runSQLiteScript(t, `
+		CREATE TABLE T ( ID INTEGER, PRICE INTEGER );
+		INSERT INTO T VALUES (1, 100);
+		ATTACH CSV '`+csvPath+`' AS D;
+		SPOOL {{SPOOL}};
+		SELECT D.NAME, T.PRICE FROM T JOIN D ON T.ID = D.ID;
+		SPOOL OFF;
+		ROLLBACK;`)
+	expect := []string{"foo", "100"}
+	if len(records) < 1 || !reflect.DeepEqual(records[len(records)-1], expect) {
+		t.Fatalf("expect %v, but %v", expect, records)
+	}
+}
+
+func TestAttachRefusesTableOfConnection(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(csvPath, []byte("ID\n1\n"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	restoreColor := disableColor()
+	defer restoreColor()
+	info, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	other := *info.Dialect
+	cfg := New()
+	cfg.Commands = []string{
+		"CREATE TABLE T ( ID INTEGER )",
+		"ATTACH CSV '" + csvPath + "' AS T",
+	}
+	err = cfg.Run(info.Driver, info.DataSource, &other)
+	if !errors.Is(err, ErrScratchNameInUse) {
+		t.Fatalf("expect %v, but %v", ErrScratchNameInUse, err)
+	}
+}
+
+func TestAttachOnReadOnlySQLite(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(csvPath, []byte("ID,NAME\n1,foo\n"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	restoreColor := disableColor()
+	defer restoreColor()
+	info, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// The temporary tables can not be created on the read-only session,
+	// so the file is attached to the scratch database.
+	cfg := New()
+	cfg.ReadOnly = true
+	cfg.Commands = []string{
+		"ATTACH CSV '" + csvPath + "' AS D",
+		"SELECT NAME FROM D",
+	}
+	output, err := captureStdout(t, func() error {
+		return cfg.Run(info.Driver, info.DataSource, info.Dialect)
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.Contains(string(output), "foo") {
+		t.Fatalf("the attached table was not queried: %q", string(output))
+	}
+}
@@ -0,0 +1,327 @@
+package sqlbless
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hymkor/sqlbless/dialect"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/internal/sqllex"
+)
+
+var (
+	ErrBindVariableNotDeclared = errors.New("bind variable not declared")
+	ErrInvalidVariableSyntax   = errors.New("invalid syntax: expected 'VARIABLE name type'")
+	ErrInvalidExecSyntax       = errors.New("invalid syntax: expected 'EXEC :name := value'")
+)
+
+type bindVariable struct {
+	typeName string
+	conv     func(string) (any, error)
+	text     string
+	value    any
+	isSet    bool
+	isNull   bool
+}
+
+func (bv *bindVariable) set(literal string) error {
+	text, isNull := unquoteLiteral(literal)
+	if isNull {
+		bv.text, bv.value, bv.isNull, bv.isSet = "", nil, true, true
+		return nil
+	}
+	value, err := bv.conv(text)
+	if err != nil {
+		return err
+	}
+	bv.text, bv.value, bv.isNull, bv.isSet = text, value, false, true
+	return nil
+}
+
+func parseNumber(s string) (any, error) {
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s, err)
+	}
+	return v, nil
+}
+
+func parseString(s string) (any, error) {
+	return s, nil
+}
+
+func (ss *session) bindConverter(typeName string) func(string) (any, error) {
+	name, _, _ := strings.Cut(strings.ToUpper(typeName), "(")
+	name = strings.TrimSpace(name)
+	if conv := ss.Dialect.LookupConverter(name); conv != nil {
+		return conv
+	}
+	switch name {
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return func(s string) (any, error) {
+			return dialect.ParseAnyDateTime(s)
+		}
+	}
+	if dialect.IsNumericTypeName(name) {
+		return parseNumber
+	}
+	return parseString
+}
+
+// unquoteLiteral removes the single quotes enclosing a literal.
+// It reports whether the literal is NULL.
+func unquoteLiteral(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), false
+	}
+	if strings.EqualFold(s, "NULL") {
+		return "", true
+	}
+	return s, false
+}
+
+// guessValue converts a literal for which no type is declared.
+func guessValue(s string) any {
+	text, isNull := unquoteLiteral(s)
+	if isNull {
+		return nil
+	}
+	if text != strings.TrimSpace(s) {
+		return text
+	}
+	if v, err := parseNumber(text); err == nil {
+		return v
+	}
+	return text
+}
+
+func doVariable(ss *session, arg string) error {
+	name, typeName := misc.CutField(arg)
+	typeName = strings.TrimSpace(typeName)
+	if name == "" {
+		return listVariables(ss, ss.stdOut, nil)
+	}
+	if typeName == "" {
+		return listVariables(ss, ss.stdOut, []string{name})
+	}
+	name = strings.TrimPrefix(name, ":")
+	if name == "" {
+		return ErrInvalidVariableSyntax
+	}
+	if ss.bindVars == nil {
+		ss.bindVars = map[string]*bindVariable{}
+	}
+	ss.bindVars[strings.ToUpper(name)] = &bindVariable{
+		typeName: strings.ToUpper(typeName),
+		conv:     ss.bindConverter(typeName),
+	}
+	return nil
+}
+
+func listVariables(ss *session, w io.Writer, names []string) error {
+	csvw := csv.NewWriter(w)
+	csvw.Comma = rune(ss.comma())
+	defer csvw.Flush()
+	if len(names) <= 0 {
+		for name := range ss.bindVars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		name = strings.ToUpper(strings.TrimPrefix(name, ":"))
+		bv, ok := ss.bindVars[name]
+		if !ok {
+			return fmt.Errorf("%s: %w", name, ErrBindVariableNotDeclared)
+		}
+		csvw.Write([]string{name, bv.typeName})
+	}
+	return nil
+}
+
+func doPrint(ss *session, arg string) error {
+	var names []string
+	for {
+		var name string
+		name, arg = misc.CutField(arg)
+		if name == "" {
+			break
+		}
+		names = append(names, strings.ToUpper(strings.TrimPrefix(name, ":")))
+	}
+	if len(names) <= 0 {
+		for name := range ss.bindVars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	csvw := csv.NewWriter(ss.stdOut)
+	csvw.Comma = rune(ss.comma())
+	defer csvw.Flush()
+	for _, name := range names {
+		bv, ok := ss.bindVars[name]
+		if !ok {
+			return fmt.Errorf("%s: %w", name, ErrBindVariableNotDeclared)
+		}
+		value := bv.text
+		if bv.isNull || !bv.isSet {
+			value = ss.Null
+		}
+		csvw.Write([]string{name, value})
+	}
+	return nil
+}
+
+// isBindPrint reports whether the arguments of PRINT are the names of bind
+// variables. The others such as `PRINT 'text'` of T-SQL are sent to the server.
+func isBindPrint(arg string) bool {
+	for {
+		var name string
+		name, arg = misc.CutField(arg)
+		if name == "" {
+			return true
+		}
+		if !isIdentifier(strings.TrimPrefix(name, ":")) {
+			return false
+		}
+	}
+}
+
+// isBindExec reports whether the arguments of EXEC are `:name := value`.
+// The others such as `EXEC procedure` are sent to the server.
+func isBindExec(arg string) bool {
+	left, _, ok := strings.Cut(arg, ":=")
+	name := strings.TrimSpace(left)
+	return ok && strings.HasPrefix(name, ":") && isIdentifier(name[1:])
+}
+
+func doExec(ss *session, arg string) error {
+	left, right, ok := strings.Cut(arg, ":=")
+	if !ok {
+		return ErrInvalidExecSyntax
+	}
+	name := strings.TrimSpace(left)
+	if !strings.HasPrefix(name, ":") || len(name) < 2 {
+		return ErrInvalidExecSyntax
+	}
+	name = strings.ToUpper(name[1:])
+	bv, ok := ss.bindVars[name]
+	if !ok {
+		return fmt.Errorf("%s: %w", name, ErrBindVariableNotDeclared)
+	}
+	if err := bv.set(right); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	fmt.Fprintln(ss.stdErr, "Ok")
+	return nil
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c >= 0x80
+}
+
+func isIdentifierChar(c byte) bool {
+	return isIdentifierStart(c) || ('0' <= c && c <= '9') || c == '$' || c == '#'
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || !isIdentifierStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isIdentifierChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// expandBindVariables replaces `:name` for declared variables and `?`
+// of the dialects whose parameter marker is `?` with the placeholders of
+// the current dialect. Values not set yet are asked with
+// commandIn.ReadLine. When the query contains no bind variables, it is
+// returned as it is and args is nil.
+func (ss *session) expandBindVariables(ctx context.Context, query string, in commandIn) (string, []any, error) {
+	ph := ss.Dialect.PlaceHolder
+	if ph == nil {
+		return query, nil, nil
+	}
+	var buffer strings.Builder
+	found := false
+	question := 0
+	tokens := sqllex.Tokens(query, ss.Dialect.Syntax)
+	for i := 0; i < len(tokens); i++ {
+		tk := tokens[i]
+		if tk.Kind != sqllex.Symbol {
+			buffer.WriteString(tk.Text)
+			continue
+		}
+		var next sqllex.Token
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch {
+		case tk.Text == ":" && next.Kind == sqllex.Symbol && next.Text == ":":
+			buffer.WriteString("::")
+			i++
+		case tk.Text == ":" && next.Kind == sqllex.Word && isIdentifierStart(next.Text[0]):
+			i++
+			name := strings.ToUpper(next.Text)
+			bv, ok := ss.bindVars[name]
+			if !ok {
+				buffer.WriteString(tk.Text + next.Text)
+				continue
+			}
+			if !bv.isSet {
+				text, err := in.ReadLine(ctx, fmt.Sprintf("Enter value for %s: ", name))
+				if err != nil {
+					ph.Values()
+					return "", nil, err
+				}
+				if err := bv.set(text); err != nil {
+					ph.Values()
+					return "", nil, fmt.Errorf("%s: %w", name, err)
+				}
+			}
+			if bv.isNull {
+				buffer.WriteString(ph.Make(nil))
+			} else {
+				buffer.WriteString(ph.Make(bv.value))
+			}
+			found = true
+		case tk.Text == "?" && ss.Dialect.QuestionMarkParameter:
+			question++
+			text, err := in.ReadLine(ctx, fmt.Sprintf("Enter value for ?%d: ", question))
+			if err != nil {
+				ph.Values()
+				return "", nil, err
+			}
+			buffer.WriteString(ph.Make(guessValue(text)))
+			found = true
+		default:
+			buffer.WriteString(tk.Text)
+		}
+	}
+	args := ph.Values()
+	if !found {
+		return query, nil, nil
+	}
+	return buffer.String(), args, nil
+}
+
+func echoArgs(spool io.Writer, args []any) {
+	if s := joinAny(args); s != "" {
+		misc.Echo(spool, s)
+	}
+}
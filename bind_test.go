@@ -0,0 +1,108 @@
+package sqlbless
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBindVariable(t *testing.T) {
+	records := runSQLiteScript(t, `
+		CREATE TABLE TESTTBL
+		( ID   NUMERIC,
+		  NAME CHAR VARYING(20),
+		 PRIMARY KEY (ID) );
+
+		INSERT INTO TESTTBL VALUES (10,'FOO');
+		INSERT INTO TESTTBL VALUES (20,'BAR');
+
+		VARIABLE id NUMBER;
+		VARIABLE name VARCHAR2(20);
+		EXEC :id := 20;
+		EXEC :name := 'it''s';
+
+		UPDATE TESTTBL SET NAME = :name WHERE ID = :id;
+
+		SPOOL {{SPOOL}};
+		SELECT 'ROW=',ID,NAME FROM TESTTBL WHERE ID = :ID;
+		PRINT id name;
+		SPOOL OFF;
+		ROLLBACK;
+	`)
+	foundRow := false
+	foundPrint := false
+	for _, r := range records {
+		if len(r) >= 3 && r[0] == "ROW=" && r[1] == "20" && r[2] == "it's" {
+			foundRow = true
+		}
+		if len(r) >= 2 && r[0] == "NAME" && r[1] == "it's" {
+			foundPrint = true
+		}
+	}
+	if !foundRow {
+		t.Fatalf("updated row not found: %#v", records)
+	}
+	if !foundPrint {
+		t.Fatalf("printed value not found: %#v", records)
+	}
+}
+
+func TestExpandBindVariablesKeepsLiterals(t *testing.T) {
+	ss := &session{
+		Config:  New(),
+		Dialect: sqliteDialectForTest(t),
+		bindVars: map[string]*bindVariable{
+			"ID": {typeName: "NUMBER", conv: parseNumber, text: "1", value: int64(1), isSet: true},
+		},
+	}
+	query, args, err := ss.expandBindVariables(context.TODO(), `SELECT ':id', x::int /* :id */ FROM t WHERE a = :id AND b = :undeclared -- :id`, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := `SELECT ':id', x::int /* :id */ FROM t WHERE a = $v1 AND b = :undeclared -- :id`
+	if query != expect {
+		t.Fatalf("expect %q, but %q", expect, query)
+	}
+	if len(args) != 1 {
+		t.Fatalf("expect 1 argument, but %d", len(args))
+	}
+}
+
+func TestExpandBindVariablesKeepsQuestionMarks(t *testing.T) {
+	d := *sqliteDialectForTest(t)
+	d.QuestionMarkParameter = false
+	ss := &session{Config: New(), Dialect: &d}
+	query := `SELECT data ? 'key', data ?| array['a'] FROM t`
+	result, args, err := ss.expandBindVariables(context.TODO(), query, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != query || args != nil {
+		t.Fatalf("expect %q as it is, but %q %v", query, result, args)
+	}
+}
+
+func TestIsBindCommand(t *testing.T) {
+	for arg, expected := range map[string]bool{
+		"":          true,
+		"id :name":  true,
+		"'x'":       false,
+		"@count":    false,
+		"GETDATE()": false,
+	} {
+		if isBindPrint(arg) != expected {
+			t.Errorf("PRINT %s: expect %v", arg, expected)
+		}
+	}
+	for arg, expected := range map[string]bool{
+		":id := 20":        true,
+		" :name:='it''s'":  true,
+		"sp_who":           false,
+		"pkg.proc(1)":      false,
+		"stmt(1)":          false,
+		"@r = proc @a = 1": false,
+	} {
+		if isBindExec(arg) != expected {
+			t.Errorf("EXEC %s: expect %v", arg, expected)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -14,7 +15,10 @@ import (
 
 func main() {
 	if err := sqlbless.Run(); err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		os.Exit(1)
+		var exitErr *sqlbless.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		os.Exit(sqlbless.ExitCode(err))
 	}
 }
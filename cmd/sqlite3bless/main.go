@@ -12,17 +12,27 @@ import (
 
 func mains() error {
 	cfg := sqlbless.New().Bind(flag.CommandLine)
+	flag.Func("csv", "Attach the CSV/TSV file as a table named after the file (repeatable)", func(fname string) error {
+		cfg.Attach = append(cfg.Attach, fname)
+		return nil
+	})
 	flag.Parse()
 	args := flag.Args()
-	if len(args) < 1 {
-		return errors.New("usage: sqlite3bless {DBPATH or :memory:}")
+	dbPath := ":memory:"
+	if len(args) >= 1 {
+		dbPath = args[0]
+	} else if len(cfg.Attach) <= 0 {
+		return errors.New("usage: sqlite3bless {-csv FILE} {DBPATH or :memory:}")
 	}
-	return cfg.Run("sqlite3", args[0], sqlite.Entry)
+	return cfg.Run("sqlite3", dbPath, sqlite.Entry)
 }
 
 func main() {
 	if err := mains(); err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		os.Exit(1)
+		var exitErr *sqlbless.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		os.Exit(sqlbless.ExitCode(err))
 	}
 }
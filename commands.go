@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/hymkor/csvi"
 
+	"github.com/hymkor/sqlbless/rowstocsv"
 	"github.com/hymkor/sqlbless/spread"
 
 	"github.com/hymkor/sqlbless/internal/misc"
@@ -18,6 +20,7 @@ import (
 func doSelect(ctx context.Context, ss *session, query string, v *spread.Viewer, pilot commandIn, args ...any) error {
 	var rows *sql.Rows
 	var err error
+	start := time.Now()
 	if ss.tx != nil {
 		rows, err = ss.tx.QueryContext(ctx, query, args...)
 	} else {
@@ -26,20 +29,55 @@ func doSelect(ctx context.Context, ss *session, query string, v *spread.Viewer,
 	if err != nil {
 		return fmt.Errorf("query: %[1]w (%[1]T)", err)
 	}
+	executed := time.Since(start)
 	_rows, ok := misc.RowsHasNext(rows)
 	if !ok {
 		rows.Close()
+		ss.printDuration("execute", executed)
 		return ErrNoDataFound
 	}
+	if !ss.Timing {
+		return viewRows(ctx, ss, query, _rows, v, pilot)
+	}
+	firstRow := time.Since(start)
+	tr := newTimedRows(_rows)
+	err = viewRows(ctx, ss, query, tr, v, pilot)
+	select {
+	case <-tr.closed:
+	case <-ctx.Done():
+	}
+	count, end := tr.result()
+	fmt.Fprintf(ss.stdErr, "%d rows selected.\n", count)
+	ss.printDuration("execute", executed)
+	ss.printDuration("first row", firstRow)
+	if !end.IsZero() {
+		ss.printDuration("fetch", end.Sub(start))
+	}
+	return err
+}
+
+func viewRows(ctx context.Context, ss *session, title string, rows rowstocsv.Source, v *spread.Viewer, pilot commandIn) error {
+	// The timeout is for the statement and the first fetch, not for the viewer.
+	ss.stopTimeout()
+	if ss.outFormat != "" {
+		return ss.printRows(ctx, title, rows)
+	}
 	if v == nil {
 		v = newViewer(ss)
 	}
+	rows, teed, err := ss.spoolRows(title, rows)
+	if err != nil {
+		return err
+	}
+	if teed {
+		v.Spool = nil
+	}
 	if ss.automatic() {
 		v.Pilot = &misc.CsviNoOperation{}
 	} else if a, ok := pilot.AutoPilotForCsvi(); ok {
 		v.Pilot = a
 	}
-	err = v.View(ctx, query, _rows, ss.termOut)
+	err = v.View(ctx, title, rows, ss.termOut)
 	if errors.Is(err, io.EOF) {
 		return nil
 	}
@@ -67,13 +105,52 @@ func doTCL(ctx context.Context, ss *session, query string) error {
 	if ss.tx == nil {
 		return ErrNoActiveTransaction
 	}
+	start := time.Now()
 	_, err := ss.tx.ExecContext(ctx, query)
 	if err == nil {
 		fmt.Fprintln(ss.stdErr, "Ok")
+		ss.printElapsed("execute", start)
+	}
+	return err
+}
+
+// doSessionSetup executes the statement such as SET outside of a
+// transaction, so that it can be replayed on a new connection.
+func doSessionSetup(ctx context.Context, ss *session, query string) error {
+	start := time.Now()
+	_, err := ss.conn.ExecContext(ctx, query)
+	if err == nil {
+		fmt.Fprintln(ss.stdErr, "Ok")
+		ss.printElapsed("execute", start)
 	}
 	return err
 }
 
+// doBlock executes an anonymous block such as BEGIN ... END. It is executed
+// in the transaction, because the block may update tables.
+func doBlock(ctx context.Context, ss *session, query string, commandIn commandIn) error {
+	query, args, err := ss.expandBindVariables(ctx, query, commandIn)
+	if err != nil {
+		return err
+	}
+	echoArgs(ss.spool, args)
+	isNewTx := (ss.tx == nil)
+	if err := ss.beginTx(ss.stdErr); err != nil {
+		return err
+	}
+	start := time.Now()
+	if _, err := ss.tx.ExecContext(ctx, query, args...); err != nil {
+		if isNewTx && ss.tx != nil {
+			ss.tx.Rollback()
+			ss.tx = nil
+		}
+		return err
+	}
+	fmt.Fprintln(ss.stdErr, "Ok")
+	ss.printElapsed("execute", start)
+	return nil
+}
+
 func (ss *session) commit() error {
 	var err error
 	if ss.tx != nil {
@@ -98,16 +175,23 @@ func (ss *session) rollback() error {
 	return err
 }
 
-func (ss *session) beginTx(ctx context.Context, w io.Writer) error {
+func (ss *session) beginTx(w io.Writer) error {
 	if ss.tx != nil {
 		return nil
 	}
 	fmt.Fprintln(w, "Starts a transaction")
 	var err error
-	ss.tx, err = ss.conn.BeginTx(ctx, nil)
+	// The context of the statement is not used, because database/sql
+	// rolls back the transaction when its context is done.
+	ss.tx, err = ss.conn.BeginTx(context.Background(), nil)
 	if err != nil {
 		return fmt.Errorf("BeginTx: %[1]w (%[1]T)", err)
 	}
+	if err := ss.setReadOnlyTransaction(context.Background()); err != nil {
+		ss.tx.Rollback()
+		ss.tx = nil
+		return err
+	}
 	return nil
 }
 
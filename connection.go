@@ -0,0 +1,324 @@
+package sqlbless
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hymkor/sqlbless/dialect"
+
+	"github.com/hymkor/sqlbless/internal/history"
+	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/internal/sqlcompletion"
+	"github.com/hymkor/sqlbless/internal/sqllex"
+)
+
+var (
+	ErrInvalidConnectSyntax = errors.New("invalid syntax: expected 'CONNECT name [driver] dsn' or 'CONNECT name @profile'")
+	ErrConnectionExists     = errors.New("the connection already exists")
+	ErrConnectionNotFound   = errors.New("no such connection")
+	ErrDisconnectMain       = errors.New("the first connection can not be disconnected")
+)
+
+// mainConnection is the name of the connection opened at startup.
+const mainConnection = "main"
+
+// connection is a database connection of the session. The state of the
+// active one is held by the fields of the session and is stored here
+// when another connection becomes active.
+type connection struct {
+	name     string
+	driver   string
+	readOnly bool
+	color    string  // the escape sequence for the prompt
+	db       *sql.DB // the one of the main connection is closed by Config.Run
+	dialect  *dialect.Entry
+	conn     *sql.Conn
+	tx       *sql.Tx
+	history  *history.History
+	setup    []string
+	complete func(context.Context, []string) ([]string, []string)
+}
+
+// storeConnection saves the state of the active connection.
+func (ss *session) storeConnection() {
+	c := ss.current
+	if c == nil {
+		return
+	}
+	c.dialect, c.conn, c.tx, c.history, c.setup = ss.Dialect, ss.conn, ss.tx, ss.history, ss.setup
+}
+
+// loadConnection makes c the active connection.
+func (ss *session) loadConnection(c *connection) {
+	ss.Dialect, ss.conn, ss.tx, ss.history, ss.setup = c.dialect, c.conn, c.tx, c.history, c.setup
+	ss.current = c
+}
+
+func (ss *session) findConnection(name string) *connection {
+	for _, c := range ss.connections {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// forEachConnection calls f with each connection made active in turn
+// and restores the active one.
+func (ss *session) forEachConnection(f func() error) error {
+	if ss.current == nil {
+		return f()
+	}
+	ss.storeConnection()
+	active := ss.current
+	var errs []error
+	for _, c := range ss.connections {
+		ss.loadConnection(c)
+		if err := f(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+		ss.storeConnection()
+	}
+	ss.loadConnection(active)
+	return errors.Join(errs...)
+}
+
+// openTransactions returns the names of the connections in transaction.
+func (ss *session) openTransactions() []string {
+	var names []string
+	ss.forEachConnection(func() error {
+		if ss.tx != nil {
+			names = append(names, ss.current.name)
+		}
+		return nil
+	})
+	return names
+}
+
+// errTransactionsNotClosed returns the error for the transactions left
+// open, with the names of the connections when there are two or more.
+func (ss *session) errTransactionsNotClosed(names []string) error {
+	if len(ss.connections) <= 1 {
+		return ErrTransactionIsNotClosed
+	}
+	return fmt.Errorf("%w (%s)", ErrTransactionIsNotClosed, strings.Join(names, ", "))
+}
+
+// complete returns the candidates of completion with the cache of the
+// active connection.
+func (ss *session) complete(ctx context.Context, fields []string) ([]string, []string) {
+	if ss.current == nil {
+		return sqlcompletion.New(ss.Dialect, ss.conn)(ctx, fields)
+	}
+	if ss.current.complete == nil {
+		ss.current.complete = sqlcompletion.New(ss.Dialect, ss.conn)
+	}
+	return ss.current.complete(ctx, fields)
+}
+
+// activeHistory is the history of the active connection for the editor.
+type activeHistory struct {
+	ss *session
+}
+
+func (h activeHistory) Len() int {
+	return h.ss.history.Len()
+}
+
+func (h activeHistory) At(n int) string {
+	return h.ss.history.At(n)
+}
+
+// closeConnections rolls back the transactions left open and closes the
+// connections. The pools except the main one are also closed.
+func (ss *session) closeConnections() {
+	ss.forEachConnection(func() error {
+		if ss.tx != nil {
+			ss.rollback()
+		}
+		return nil
+	})
+	for i, c := range ss.connections {
+		c.conn.Close()
+		if i > 0 {
+			c.db.Close()
+		}
+	}
+}
+
+// redactDataSources returns the statement whose passwords written in the
+// DSNs of CONNECT and COPY FROM are replaced with `****`, so that they are
+// not recorded into the spool and the history.
+func redactDataSources(query string, syntax dialect.Syntax) string {
+	_, arg := misc.CutField(sqllex.TrimLeft(query, syntax))
+	var dsns []string
+	switch strings.ToUpper(sqllex.Keyword(query, syntax)) {
+	case "CONNECT":
+		_, arg = misc.CutField(arg)
+		for {
+			var word string
+			if word, arg = cutWord(arg); word == "" {
+				break
+			}
+			dsns = append(dsns, word)
+		}
+	case "COPY":
+		if !isCopyFrom(arg) {
+			return query
+		}
+		_, arg = misc.CutField(arg)
+		for {
+			var word string
+			if word, arg = cutWord(arg); word == "" || strings.EqualFold(word, "QUERY") {
+				break
+			}
+			dsns = append(dsns, word)
+		}
+	default:
+		return query
+	}
+	for _, dsn := range dsns {
+		if redacted := dialect.RedactDataSource(dsn); redacted != dsn {
+			query = strings.Replace(query, dsn, redacted, 1)
+		}
+	}
+	return query
+}
+
+// doConnect opens the connection and makes it active.
+func doConnect(ctx context.Context, ss *session, arg string) error {
+	name, arg := misc.CutField(arg)
+	var args []string
+	for {
+		var word string
+		if word, arg = cutWord(arg); word == "" {
+			break
+		}
+		args = append(args, word)
+	}
+	if name == "" || len(args) <= 0 {
+		return ErrInvalidConnectSyntax
+	}
+	if ss.findConnection(name) != nil {
+		return fmt.Errorf("%w: %s", ErrConnectionExists, name)
+	}
+	info, p, err := readDBInfo(args)
+	if err != nil {
+		return err
+	}
+	var color string
+	var readOnly bool
+	if p != nil {
+		if color, err = colorSequence(p.Options["color"]); err != nil {
+			return err
+		}
+		if readOnly, err = p.readOnly(); err != nil {
+			return err
+		}
+	}
+	db, err := sql.Open(info.Driver, info.DataSource)
+	if err != nil {
+		return fmt.Errorf("sql.Open: %w (%T)", dialect.RedactError(info.DataSource, err), err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("db.Ping: %w", dialect.RedactError(info.DataSource, err))
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("db.Conn: %w", dialect.RedactError(info.DataSource, err))
+	}
+	if readOnly {
+		if err := setReadOnly(ctx, conn, info.Dialect); err != nil {
+			conn.Close()
+			db.Close()
+			return err
+		}
+	}
+	c := &connection{
+		name:     name,
+		driver:   info.Driver,
+		readOnly: readOnly,
+		color:    color,
+		db:       db,
+		dialect:  info.Dialect,
+		conn:     conn,
+		history:  &history.History{},
+	}
+	ss.storeConnection()
+	ss.connections = append(ss.connections, c)
+	ss.loadConnection(c)
+	if err := ss.applyServerTimeout(ctx); err != nil {
+		fmt.Fprintln(ss.stdErr, err.Error())
+	}
+	fmt.Fprintf(ss.stdErr, "Connected to %s as %s\n", info.Driver, name)
+	return nil
+}
+
+// doSwitch makes the connection active. The transaction of the previous
+// connection is kept open.
+func doSwitch(ss *session, arg string) error {
+	name, _ := misc.CutField(arg)
+	c := ss.findConnection(name)
+	if c == nil {
+		return fmt.Errorf("%w: %s", ErrConnectionNotFound, name)
+	}
+	ss.storeConnection()
+	ss.loadConnection(c)
+	fmt.Fprintf(ss.stdErr, "Switched to %s\n", c.name)
+	return nil
+}
+
+// doDisconnect closes the connection opened by CONNECT. When it is the
+// active one, the main connection becomes active.
+func doDisconnect(ss *session, arg string) error {
+	name, _ := misc.CutField(arg)
+	c := ss.findConnection(name)
+	if c == nil {
+		return fmt.Errorf("%w: %s", ErrConnectionNotFound, name)
+	}
+	if c == ss.connections[0] {
+		return ErrDisconnectMain
+	}
+	ss.storeConnection()
+	if c.tx != nil {
+		return ss.errTransactionsNotClosed([]string{c.name})
+	}
+	if c == ss.current {
+		ss.loadConnection(ss.connections[0])
+	}
+	for i, c1 := range ss.connections {
+		if c1 == c {
+			ss.connections = append(ss.connections[:i], ss.connections[i+1:]...)
+			break
+		}
+	}
+	c.conn.Close()
+	c.db.Close()
+	fmt.Fprintf(ss.stdErr, "Disconnected from %s\n", c.name)
+	return nil
+}
+
+// doConnections lists the connections with their transaction state.
+// The active one is marked with `*`.
+func doConnections(ss *session) {
+	ss.storeConnection()
+	w := csv.NewWriter(ss.stdOut)
+	w.Write([]string{"", "NAME", "DRIVER", "TRANSACTION"})
+	for _, c := range ss.connections {
+		active, tx := "", "none"
+		if c == ss.current {
+			active = "*"
+		}
+		if c.tx != nil {
+			tx = "open"
+		}
+		w.Write([]string{active, c.name, c.driver, tx})
+	}
+	w.Flush()
+}
@@ -0,0 +1,127 @@
+package sqlbless
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func TestConnections(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "other.db")
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CONNECT other sqlite3 '` + dbPath + `';
+			CREATE TABLE T ( ID INTEGER );
+			INSERT INTO T VALUES (1);
+			SWITCH main;
+			SPOOL ` + spool + `;
+			CONNECTIONS;
+			SPOOL OFF;
+			SWITCH other;
+			COMMIT;
+			INSERT INTO T VALUES (2);
+			SWITCH main;`,
+	})
+	err := runScriptFile(t, filepath.Join(dir, "main.sql"))
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitUncommitted {
+		t.Fatalf("expect the exit code %d, but %v", ExitUncommitted, err)
+	}
+
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	expect := ",NAME,DRIVER,TRANSACTION|*,main,sqlite3,none|,other,sqlite3,open"
+	if got := strings.Join(lines, "|"); !strings.HasSuffix(got, expect) {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+
+	d, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", dbPath})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	db, err := sql.Open(d.Driver, d.DataSource)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer db.Close()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM T").Scan(&count); err != nil {
+		t.Fatal(err.Error())
+	}
+	if count != 1 {
+		t.Fatalf("expect 1 row committed, but %d", count)
+	}
+}
+
+func TestRedactDataSources(t *testing.T) {
+	syntax := sqliteDialectForTest(t).Syntax
+	for _, c := range []struct {
+		query    string
+		expected string
+	}{
+		{
+			query:    "CONNECT other postgres://app:secret@db/sales;",
+			expected: "CONNECT other postgres://app:****@db/sales;",
+		},
+		{
+			query:    "connect other sqlserver 'server=db;user id=sa;password=secret'",
+			expected: "connect other sqlserver 'server=db;user id=sa;password=****'",
+		},
+		{
+			query:    "CONNECT other postgres://app:?@db/sales",
+			expected: "CONNECT other postgres://app:?@db/sales",
+		},
+		{
+			query:    "COPY FROM oracle://scott:tiger@db:1521/XE QUERY 'SELECT * FROM EMP' TO EMP",
+			expected: "COPY FROM oracle://scott:****@db:1521/XE QUERY 'SELECT * FROM EMP' TO EMP",
+		},
+		{
+			query:    "SELECT 'postgres://app:secret@db/sales' FROM DUAL",
+			expected: "SELECT 'postgres://app:secret@db/sales' FROM DUAL",
+		},
+	} {
+		if result := redactDataSources(c.query, syntax); result != c.expected {
+			t.Fatalf("expect %q, but %q", c.expected, result)
+		}
+	}
+}
+
+func TestConnectDoesNotSpoolPassword(t *testing.T) {
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			SPOOL ` + spool + `;
+			CONNECT other unknown://app:secret@db/sales;`,
+	})
+	err := runScriptFile(t, filepath.Join(dir, "main.sql"))
+	if err == nil {
+		t.Fatal("expect an error of the unknown driver")
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Fatalf("expect the password redacted, but %q", err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if strings.Contains(string(output), "secret") || !strings.Contains(string(output), "app:****@db") {
+		t.Fatalf("expect the password redacted, but %q", output)
+	}
+}
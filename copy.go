@@ -0,0 +1,288 @@
+package sqlbless
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hymkor/sqlbless/dialect"
+	"github.com/hymkor/sqlbless/rowstocsv"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+)
+
+var (
+	ErrInvalidCopySyntax = errors.New("invalid syntax: expected 'COPY FROM dsn QUERY 'select-statement' TO table [CREATE|APPEND|REPLACE]'")
+	ErrNoColumnsToCopy   = errors.New("the query of COPY returns no columns")
+)
+
+type copySpec struct {
+	source []string
+	query  string
+	table  string
+	mode   string
+}
+
+// isCopyFrom reports whether the arguments of COPY are the ones of
+// SQL-Bless. The others such as `COPY table FROM file` of PostgreSQL
+// are sent to the server.
+func isCopyFrom(arg string) bool {
+	word, _ := misc.CutField(arg)
+	return strings.EqualFold(word, "FROM")
+}
+
+// parseCopyArgs parses `FROM dsn QUERY 'statement' TO table [CREATE|APPEND|REPLACE]`.
+func parseCopyArgs(arg string) (*copySpec, error) {
+	spec := &copySpec{mode: "APPEND"}
+	from, arg := misc.CutField(arg)
+	if !strings.EqualFold(from, "FROM") {
+		return nil, ErrInvalidCopySyntax
+	}
+	for {
+		var word string
+		word, arg = cutWord(arg)
+		if word == "" {
+			return nil, ErrInvalidCopySyntax
+		}
+		if strings.EqualFold(word, "QUERY") {
+			break
+		}
+		spec.source = append(spec.source, word)
+	}
+	spec.query, arg = cutWord(arg)
+	to, arg := misc.CutField(arg)
+	spec.table, arg = misc.CutField(arg)
+	if len(spec.source) <= 0 || strings.TrimSpace(spec.query) == "" ||
+		!strings.EqualFold(to, "TO") || spec.table == "" {
+		return nil, ErrInvalidCopySyntax
+	}
+	if mode, rest := misc.CutField(arg); mode != "" {
+		switch spec.mode = strings.ToUpper(mode); spec.mode {
+		case "CREATE", "APPEND", "REPLACE":
+		default:
+			return nil, ErrInvalidCopySyntax
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, ErrInvalidCopySyntax
+		}
+	}
+	return spec, nil
+}
+
+// execDDL executes the statement outside the transaction, or in it when
+// the dialect can do it in the transaction.
+func (ss *session) execDDL(ctx context.Context, query string) error {
+	if ss.tx == nil {
+		_, err := ss.conn.ExecContext(ctx, query)
+		return err
+	}
+	if f := ss.Dialect.IsTransactionSafe; f != nil && f(query) {
+		_, err := ss.tx.ExecContext(ctx, query)
+		return err
+	}
+	return ErrTransactionIsNotClosed
+}
+
+// createTableFor creates the table with the types of this dialect mapped
+// from the columns of the other database.
+func (ss *session) createTableFor(ctx context.Context, table string, columns []string, specs []dialect.ColumnTypeSpec) error {
+	lines := make([]string, len(columns))
+	for i, name := range columns {
+		lines[i] = ss.Dialect.EncloseIdentifierIfNeed(name) + " " + ss.Dialect.TypeNameOf(specs[i])
+	}
+	ddl := fmt.Sprintf("CREATE TABLE %s (\n    %s\n)", table, strings.Join(lines, ",\n    "))
+	misc.Echo(ss.spool, ddl)
+	return ss.execDDL(ctx, ddl)
+}
+
+const (
+	// copyBatchRows is the most rows inserted by a statement of COPY.
+	copyBatchRows = 100
+
+	// copyBatchParameters is the most parameters of a statement of COPY,
+	// which is the limit of old SQLite3.
+	copyBatchParameters = 999
+)
+
+// insertSQL returns the INSERT statement with the parameters of n rows.
+func (ss *session) insertSQL(table string, columns []string, n int) string {
+	return ss.insertValuesSQL(table, ss.identifierList(columns), len(columns), n)
+}
+
+// insertValuesSQL is similar with insertSQL, but it takes the list of the
+// columns enclosed already and the number of them.
+func (ss *session) insertValuesSQL(table, columnList string, width, n int) string {
+	holder := ss.Dialect.PlaceHolder
+	tuples := make([]string, n)
+	marks := make([]string, width)
+	for r := range tuples {
+		for i := range marks {
+			marks[i] = holder.Make(nil)
+		}
+		tuples[r] = "(" + strings.Join(marks, ",") + ")"
+	}
+	holder.Values()
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		table, columnList, strings.Join(tuples, ","))
+}
+
+// rowsPerInsert returns the number of rows inserted by a statement:
+// copyBatchRows rows at most when the dialect accepts VALUES of multiple
+// rows, otherwise one.
+func (ss *session) rowsPerInsert(width int) int {
+	if !ss.Dialect.MultiRowValues || width <= 0 {
+		return 1
+	}
+	batch := copyBatchParameters / width
+	if batch > copyBatchRows {
+		return copyBatchRows
+	} else if batch < 1 {
+		return 1
+	}
+	return batch
+}
+
+// copyRows inserts the rows into the table in the transaction and returns
+// the number of them.
+// The rows are inserted by the statements of rowsPerInsert rows.
+func (ss *session) copyRows(ctx context.Context, tx *sql.Tx, rows rowstocsv.Source, table string, columns []string, specs []dialect.ColumnTypeSpec) (int64, error) {
+	batch := ss.rowsPerInsert(len(columns))
+	stmt, err := tx.PrepareContext(ctx, ss.insertSQL(table, columns, batch))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	holder := ss.Dialect.PlaceHolder
+	defer holder.Values() // not to leave the values of a broken batch
+	values := make([]any, len(specs))
+	refs := make([]any, len(specs))
+	for i := range values {
+		refs[i] = &values[i]
+	}
+	pending := 0
+	var count int64
+	flush := func() error {
+		// Values may reuse its slice when insertSQL makes the parameters
+		args := append([]any{}, holder.Values()...)
+		var err error
+		if pending == batch {
+			_, err = stmt.ExecContext(ctx, args...)
+		} else {
+			_, err = tx.ExecContext(ctx, ss.insertSQL(table, columns, pending), args...)
+		}
+		if err != nil {
+			if pending == 1 {
+				return fmt.Errorf("row %d: %w", count+1, err)
+			}
+			return fmt.Errorf("rows %d-%d: %w", count+1, count+int64(pending), err)
+		}
+		count += int64(pending)
+		pending = 0
+		return nil
+	}
+	for rows.Next() {
+		if err := rows.Scan(refs...); err != nil {
+			return count, err
+		}
+		for i, v := range values {
+			// Texts are often scanned as []byte, which some drivers
+			// send as binaries. The buffer may be reused on the next Scan.
+			if b, ok := v.([]byte); ok {
+				if specs[i].Kind != dialect.KindBinary {
+					v = string(b)
+				} else {
+					v = append([]byte{}, b...)
+				}
+			}
+			holder.Make(v)
+		}
+		if pending++; pending >= batch {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	if pending > 0 {
+		if err := flush(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// doCopy copies the result of the query on another database into the
+// table in the transaction of this session.
+func doCopy(ctx context.Context, ss *session, arg string) error {
+	spec, err := parseCopyArgs(arg)
+	if err != nil {
+		return err
+	}
+	info, _, err := readDBInfo(spec.source)
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open(info.Driver, info.DataSource)
+	if err != nil {
+		return fmt.Errorf("sql.Open: %w (%T)", dialect.RedactError(info.DataSource, err), err)
+	}
+	defer db.Close()
+
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, spec.query)
+	if err != nil {
+		return fmt.Errorf("query: %w (%T)", dialect.RedactError(info.DataSource, err), err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) <= 0 {
+		return ErrNoColumnsToCopy
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	specs := make([]dialect.ColumnTypeSpec, len(columnTypes))
+	for i, ct := range columnTypes {
+		specs[i] = dialect.SpecOf(ct)
+	}
+	if spec.mode == "CREATE" {
+		if err := ss.createTableFor(ctx, spec.table, columns, specs); err != nil {
+			return err
+		}
+	}
+
+	isNewTx := (ss.tx == nil)
+	if err := ss.beginTx(ss.stdErr); err != nil {
+		return err
+	}
+	rollbackIfNew := func() {
+		if isNewTx && ss.tx != nil {
+			ss.tx.Rollback()
+			ss.tx = nil
+		}
+	}
+	if spec.mode == "REPLACE" {
+		if _, err := ss.tx.ExecContext(ctx, "DELETE FROM "+spec.table); err != nil {
+			rollbackIfNew()
+			return err
+		}
+	}
+	count, err := ss.copyRows(ctx, ss.tx, rows, spec.table, columns, specs)
+	if err != nil {
+		rollbackIfNew()
+		return err
+	}
+	fmt.Fprintf(ss.stdErr, "%d row(s) copied into %s\n", count, spec.table)
+	ss.printElapsed("copy", start)
+	return nil
+}
@@ -0,0 +1,144 @@
+package sqlbless
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func TestParseCopyArgs(t *testing.T) {
+	for _, c := range []struct {
+		arg    string
+		expect *copySpec
+		err    error
+	}{
+		{
+			arg: "FROM sqlite3 src.db QUERY 'SELECT * FROM T' TO T2",
+			expect: &copySpec{
+				source: []string{"sqlite3", "src.db"},
+				query:  "SELECT * FROM T",
+				table:  "T2",
+				mode:   "APPEND",
+			},
+		},
+		{
+			arg: "from postgres 'host=localhost user=foo' query 'SELECT * FROM T WHERE NAME=''x''' to T create",
+			expect: &copySpec{
+				source: []string{"postgres", "host=localhost user=foo"},
+				query:  "SELECT * FROM T WHERE NAME='x'",
+				table:  "T",
+				mode:   "CREATE",
+			},
+		},
+		{arg: "T FROM 'file.csv'", err: ErrInvalidCopySyntax},
+		{arg: "FROM QUERY 'SELECT 1' TO T", err: ErrInvalidCopySyntax},
+		{arg: "FROM sqlite3 src.db 'SELECT 1' TO T", err: ErrInvalidCopySyntax},
+		{arg: "FROM sqlite3 src.db QUERY 'SELECT 1' T", err: ErrInvalidCopySyntax},
+		{arg: "FROM sqlite3 src.db QUERY 'SELECT 1' TO T MERGE", err: ErrInvalidCopySyntax},
+	} {
+		spec, err := parseCopyArgs(c.arg)
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Fatalf("%s: expect %v, but %v", c.arg, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", c.arg, err.Error())
+		}
+		if !reflect.DeepEqual(spec, c.expect) {
+			t.Fatalf("%s: expect %#v, but %#v", c.arg, c.expect, spec)
+		}
+	}
+}
+
+func TestCopy(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.db")
+	d, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", srcPath})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	db, err := sql.Open(d.Driver, d.DataSource)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, s := range []string{
+		"CREATE TABLE SRC ( ID INTEGER, NAME TEXT, PRICE REAL )",
+		"INSERT INTO SRC VALUES (1, 'foo', 1.5)",
+		"INSERT INTO SRC VALUES (2, NULL, 2.25)",
+	} {
+		if _, err := db.Exec(s); err != nil {
+			db.Close()
+			t.Fatal(err.Error())
+		}
+	}
+	db.Close()
+
+	spool := filepath.Join(dir, "output.lst")
+	source := "sqlite3 '" + srcPath + "'"
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			COPY FROM ` + source + ` QUERY 'SELECT * FROM SRC' TO DST CREATE;
+			COPY FROM ` + source + ` QUERY 'SELECT ID+10 AS ID, NAME, PRICE FROM SRC' TO DST;
+			SPOOL ` + spool + `;
+			SELECT ID, COALESCE(NAME, 'null'), PRICE FROM DST ORDER BY ID;
+			COPY FROM ` + source + ` QUERY 'SELECT * FROM SRC WHERE NAME = ''foo''' TO DST REPLACE;
+			SELECT COUNT(*) FROM DST;
+			SPOOL OFF;
+			ROLLBACK;`,
+	})
+	if err := runScriptFile(t, filepath.Join(dir, "main.sql")); err != nil {
+		t.Fatal(err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") && !strings.Contains(line, "COPY") {
+			lines = append(lines, line)
+		}
+	}
+	expect := "1,foo,1.5|2,null,2.25|11,foo,1.5|12,null,2.25|1 row(s) copied into DST|COUNT(*)|1"
+	if got := strings.Join(lines, "|"); !strings.HasSuffix(got, expect) {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+}
+
+func TestCopyWithoutColumns(t *testing.T) {
+	dir := t.TempDir()
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CREATE TABLE T ( A INTEGER );
+			COPY FROM sqlite3 :memory: QUERY 'CREATE TABLE Z ( A INTEGER )' TO T;`,
+	})
+	if err := runScriptFile(t, filepath.Join(dir, "main.sql")); !errors.Is(err, ErrNoColumnsToCopy) {
+		t.Fatalf("expect %v, but %v", ErrNoColumnsToCopy, err)
+	}
+}
+
+func TestCopyInBatches(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.db")
+	source := "sqlite3 '" + srcPath + "'"
+	// 250 rows are inserted by the statements of 100, 100 and 50 rows.
+	records := runSQLiteScript(t, `
+		COPY FROM `+source+` QUERY 'WITH RECURSIVE N(I) AS (SELECT 1 UNION ALL SELECT I+1 FROM N WHERE I < 250) SELECT I, ''x'' AS S FROM N' TO NUMS CREATE;
+		SPOOL {{SPOOL}};
+		SELECT COUNT(*), SUM(I) FROM NUMS;
+		SPOOL OFF;
+		ROLLBACK;`)
+	expect := []string{"250", "31375"}
+	if len(records) < 1 || !reflect.DeepEqual(records[len(records)-1], expect) {
+		t.Fatalf("expect %v, but %v", expect, records)
+	}
+}
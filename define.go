@@ -0,0 +1,237 @@
+package sqlbless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mattn/go-tty"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+)
+
+var (
+	ErrSubstitutionNotDefined = errors.New("substitution variable not defined")
+	ErrInvalidDefineSyntax    = errors.New("invalid syntax: expected 'DEFINE name = value'")
+	ErrInvalidAcceptSyntax    = errors.New("invalid syntax: expected 'ACCEPT name [DEFAULT value] [PROMPT text] [HIDE]'")
+)
+
+// cutWord is similar with misc.CutField, but it also removes single quotes
+// enclosing the word.
+func cutWord(s string) (string, string) {
+	s = strings.TrimLeft(s, " \n\r\t\v")
+	if len(s) > 0 && s[0] == '\'' {
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return strings.ReplaceAll(s[1:i], "''", "'"), s[i+1:]
+		}
+		return strings.ReplaceAll(s[1:], "''", "'"), ""
+	}
+	return misc.CutField(s)
+}
+
+func unquoteDefineValue(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		q := s[:1]
+		return strings.ReplaceAll(s[1:len(s)-1], q+q, q)
+	}
+	return s
+}
+
+func (ss *session) define(name, value string) {
+	if ss.defines == nil {
+		ss.defines = map[string]string{}
+	}
+	ss.defines[strings.ToUpper(name)] = value
+}
+
+func (ss *session) lookupDefine(name string) (string, bool) {
+	value, ok := ss.defines[strings.ToUpper(name)]
+	return value, ok
+}
+
+func printDefine(w io.Writer, name, value string) {
+	fmt.Fprintf(w, "DEFINE %s = \"%s\"\n", name, value)
+}
+
+func doDefine(ss *session, arg string) error {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		names := make([]string, 0, len(ss.defines))
+		for name := range ss.defines {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			printDefine(ss.stdOut, name, ss.defines[name])
+		}
+		return nil
+	}
+	name, value, ok := strings.Cut(arg, "=")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrInvalidDefineSyntax
+	}
+	if !ok {
+		value, ok := ss.lookupDefine(name)
+		if !ok {
+			return fmt.Errorf("%s: %w", name, ErrSubstitutionNotDefined)
+		}
+		printDefine(ss.stdOut, strings.ToUpper(name), value)
+		return nil
+	}
+	ss.define(name, unquoteDefineValue(value))
+	return nil
+}
+
+func doUndefine(ss *session, arg string) error {
+	for {
+		var name string
+		name, arg = misc.CutField(arg)
+		if name == "" {
+			return nil
+		}
+		delete(ss.defines, strings.ToUpper(name))
+	}
+}
+
+// readHidden asks the user for a line on the terminal without showing
+// the input, which is not recorded into the spool either.
+func readHidden(prompt string) (string, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("%s: %w", strings.TrimSpace(prompt), ErrCanNotAskValue)
+	}
+	t, err := tty.Open()
+	if err != nil {
+		return "", err
+	}
+	defer t.Close()
+	fmt.Fprint(t.Output(), prompt)
+	return t.ReadPassword()
+}
+
+func doAccept(ctx context.Context, ss *session, arg string, in commandIn) error {
+	name, arg := misc.CutField(arg)
+	if name == "" {
+		return ErrInvalidAcceptSyntax
+	}
+	prompt := fmt.Sprintf("Enter value for %s: ", name)
+	defaultValue := ""
+	hasDefault := false
+	hide := false
+	for {
+		var word string
+		word, arg = misc.CutField(arg)
+		if word == "" {
+			break
+		}
+		switch strings.ToUpper(word) {
+		case "PROMPT", "PROMPT=":
+			prompt, arg = cutWord(arg)
+		case "DEFAULT", "DEF":
+			defaultValue, arg = cutWord(arg)
+			hasDefault = true
+		case "HIDE":
+			hide = true
+		case "CHAR", "NUMBER", "DATE", "NOPROMPT":
+			// accepted for compatibility with SQL*Plus
+		default:
+			return fmt.Errorf("%s: %w", word, ErrInvalidAcceptSyntax)
+		}
+	}
+	var value string
+	var err error
+	if hide {
+		value, err = readHidden(prompt)
+	} else {
+		value, err = in.ReadLine(ctx, prompt)
+	}
+	if err != nil {
+		return err
+	}
+	if value == "" && hasDefault {
+		value = defaultValue
+	}
+	ss.define(name, value)
+	return nil
+}
+
+// substitute replaces `&name` and `&&name` in query with the values of
+// the substitution variables. `&name` asks the value every time when
+// it is not defined. `&&name` asks it only once and defines it.
+// A period just after the name terminates it and is removed.
+func (ss *session) substitute(ctx context.Context, query string, in commandIn) (string, error) {
+	if ss.noDefine || !strings.ContainsRune(query, '&') {
+		return query, nil
+	}
+	var buffer strings.Builder
+	for i := 0; i < len(query); {
+		if query[i] != '&' {
+			buffer.WriteByte(query[i])
+			i++
+			continue
+		}
+		j := i + 1
+		double := false
+		if j < len(query) && query[j] == '&' {
+			double = true
+			j++
+		}
+		k := j
+		for k < len(query) && isIdentifierChar(query[k]) {
+			k++
+		}
+		if k == j {
+			buffer.WriteString(query[i:k])
+			i = k
+			continue
+		}
+		name := query[j:k]
+		if k < len(query) && query[k] == '.' {
+			k++
+		}
+		value, ok := ss.lookupDefine(name)
+		if !ok {
+			var err error
+			value, err = in.ReadLine(ctx, fmt.Sprintf("Enter value for %s: ", name))
+			if err != nil {
+				return "", err
+			}
+			if double {
+				ss.define(name, value)
+			}
+		}
+		buffer.WriteString(value)
+		i = k
+	}
+	return buffer.String(), nil
+}
+
+// printSubstitution shows the statement before and after substitution
+// like SET VERIFY ON of SQL*Plus, and records both into the spool.
+func printSubstitution(ss *session, oldQuery, newQuery string) {
+	oldQuery = redactDataSources(oldQuery, ss.Dialect.Syntax)
+	newQuery = redactDataSources(newQuery, ss.Dialect.Syntax)
+	for _, p := range [...]struct{ title, text string }{
+		{"old", oldQuery},
+		{"new", newQuery},
+	} {
+		for i, line := range strings.Split(p.text, "\n") {
+			fmt.Fprintf(ss.termErr, "%s %3d: %s\n", p.title, i+1, line)
+		}
+	}
+	misc.EchoPrefix(ss.spool, "(old) ", oldQuery)
+	misc.EchoPrefix(ss.spool, "(new) ", newQuery)
+}
@@ -0,0 +1,38 @@
+package sqlbless
+
+import (
+	"testing"
+)
+
+func TestSubstitutionVariable(t *testing.T) {
+	records := runSQLiteScript(t, `
+		CREATE TABLE TESTTBL ( ID NUMERIC, NAME CHAR VARYING(20) );
+		INSERT INTO TESTTBL VALUES (10,'FOO');
+
+		DEFINE tbl = TESTTBL;
+		DEFINE col = 'NAME';
+
+		SPOOL {{SPOOL}};
+		SELECT 'ROW=',&col FROM &tbl. WHERE ID = 10;
+		SET DEFINE OFF;
+		SELECT 'RAW=','A&col' FROM TESTTBL;
+		SPOOL OFF;
+		ROLLBACK;
+	`)
+	foundRow := false
+	foundRaw := false
+	for _, r := range records {
+		if len(r) >= 2 && r[0] == "ROW=" && r[1] == "FOO" {
+			foundRow = true
+		}
+		if len(r) >= 2 && r[0] == "RAW=" && r[1] == "A&col" {
+			foundRaw = true
+		}
+	}
+	if !foundRow {
+		t.Fatalf("substituted row not found: %#v", records)
+	}
+	if !foundRaw {
+		t.Fatalf("not substituted row not found: %#v", records)
+	}
+}
@@ -0,0 +1,57 @@
+package dialect
+
+import (
+	"testing"
+)
+
+func TestDSNBothWays(t *testing.T) {
+	tests := []struct {
+		url      string
+		keyValue string
+	}{
+		{
+			"postgres://app:p%40ss%20word@db:5432/sales?sslmode=disable",
+			"host=db port=5432 user=app password='p@ss word' dbname=sales sslmode=disable",
+		},
+		{
+			"postgres://app@db/sales",
+			"host=db user=app dbname=sales",
+		},
+		{
+			"postgres://app:@db/sales",
+			"host=db user=app password='' dbname=sales",
+		},
+	}
+	for _, tt := range tests {
+		d, err := ParseDSN(tt.url)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if result := d.KeyValue(); result != tt.keyValue {
+			t.Errorf("url=%q, expected=%q, got=%q", tt.url, tt.keyValue, result)
+		}
+		d, err = ParseDSN(tt.keyValue)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		d.Scheme = "postgres"
+		if result := d.URL(); result != tt.url {
+			t.Errorf("keyValue=%q, expected=%q, got=%q", tt.keyValue, tt.url, result)
+		}
+	}
+	for _, dsn := range []string{"host='db", "host"} {
+		if _, err := ParseKeyValue(dsn); err == nil {
+			t.Errorf("%q: expected an error", dsn)
+		}
+	}
+	for dsn, expected := range map[string]bool{
+		"sqlserver://sa@localhost":      true,
+		"host=db user=app":              false,
+		"user:pass@tcp(localhost)/db":   false,
+		"Server=localhost;Database=db;": false,
+	} {
+		if IsURL(dsn) != expected {
+			t.Errorf("IsURL(%q) should be %v", dsn, expected)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CanQueryAndExec is implemented by both *sql.Conn and *sql.Tx.
+type CanQueryAndExec interface {
+	CanQuery
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// PlanNode is an operation of an execution plan.
+type PlanNode struct {
+	// Operation is the name of the operation shown as the tree.
+	Operation string
+
+	// Details are the values for Plan.Columns.
+	Details []string
+
+	Children []*PlanNode
+}
+
+// Plan is an execution plan returned by Entry.Explain.
+type Plan struct {
+	// Columns are the names of PlanNode.Details.
+	Columns []string
+
+	Roots []*PlanNode
+}
+
+const planIndent = "  "
+
+// Each calls yield for each node with its depth in depth-first order.
+func (p *Plan) Each(yield func(depth int, node *PlanNode) bool) {
+	var walk func(int, []*PlanNode) bool
+	walk = func(depth int, nodes []*PlanNode) bool {
+		for _, n := range nodes {
+			if !yield(depth, n) || !walk(depth+1, n.Children) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(0, p.Roots)
+}
+
+// Records returns the plan as the records of CSV. The first record is
+// the header and the operations are indented according to their depth.
+func (p *Plan) Records() [][]string {
+	header := append([]string{"OPERATION"}, p.Columns...)
+	records := [][]string{header}
+	p.Each(func(depth int, node *PlanNode) bool {
+		record := make([]string, len(header))
+		record[0] = strings.Repeat(planIndent, depth) + node.Operation
+		copy(record[1:], node.Details)
+		records = append(records, record)
+		return true
+	})
+	return records
+}
+
+// PlanRow is a row of a plan table whose nodes refer their parent with ID.
+type PlanRow struct {
+	ID       int64
+	ParentID int64
+	PlanNode
+}
+
+// BuildPlanFromRows makes a tree from rows with the parent ids.
+// Rows whose parent is not found become roots.
+func BuildPlanFromRows(columns []string, rows []*PlanRow) *Plan {
+	plan := &Plan{Columns: columns}
+	byID := make(map[int64]*PlanNode, len(rows))
+	for _, r := range rows {
+		byID[r.ID] = &r.PlanNode
+	}
+	for _, r := range rows {
+		if parent, ok := byID[r.ParentID]; ok && r.ParentID != r.ID {
+			parent.Children = append(parent.Children, &r.PlanNode)
+		} else {
+			plan.Roots = append(plan.Roots, &r.PlanNode)
+		}
+	}
+	return plan
+}
+
+func jsonScalarToString(v any) (string, bool) {
+	switch value := v.(type) {
+	case nil:
+		return "null", true
+	case string:
+		return value, true
+	case float64, bool, json.Number:
+		return fmt.Sprint(value), true
+	}
+	return "", false
+}
+
+func jsonToNodes(name string, v any, label func(string, map[string]any) string) []*PlanNode {
+	switch value := v.(type) {
+	case map[string]any:
+		node := &PlanNode{Operation: label(name, value)}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var details []string
+		for _, key := range keys {
+			if s, ok := jsonScalarToString(value[key]); ok {
+				details = append(details, key+"="+s)
+			} else {
+				node.Children = append(node.Children, jsonToNodes(key, value[key], label)...)
+			}
+		}
+		node.Details = []string{strings.Join(details, ", ")}
+		return []*PlanNode{node}
+	case []any:
+		var nodes []*PlanNode
+		for _, elem := range value {
+			nodes = append(nodes, jsonToNodes(name, elem, label)...)
+		}
+		return nodes
+	}
+	s, _ := jsonScalarToString(v)
+	return []*PlanNode{{Operation: name, Details: []string{s}}}
+}
+
+// PlanFromJSON makes a tree from a JSON document. Each object becomes a node
+// and its scalar members become the detail. label returns the operation name
+// of the object which is the value of the member `name`.
+func PlanFromJSON(source string, label func(name string, object map[string]any) string) (*Plan, error) {
+	var doc any
+	if err := json.Unmarshal([]byte(source), &doc); err != nil {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+	if label == nil {
+		label = func(name string, _ map[string]any) string { return name }
+	}
+	return &Plan{
+		Columns: []string{"DETAIL"},
+		Roots:   jsonToNodes("plan", doc, label),
+	}, nil
+}
+
+// QueryString returns the first column of the all rows joined with newlines.
+func QueryString(ctx context.Context, conn CanQuery, query string, args ...any) (string, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var buffer strings.Builder
+	for rows.Next() {
+		var s sql.NullString
+		if err := rows.Scan(&s); err != nil {
+			return "", err
+		}
+		if buffer.Len() > 0 {
+			buffer.WriteByte('\n')
+		}
+		buffer.WriteString(s.String)
+	}
+	return buffer.String(), rows.Err()
+}
+
+// Explain retrieves the execution plan of the statement without executing it.
+func (e *Entry) Explain(ctx context.Context, conn CanQueryAndExec, statement string) (*Plan, error) {
+	if e.PlanFor == nil {
+		return nil, ErrNotSupported
+	}
+	return e.PlanFor(ctx, conn, statement)
+}
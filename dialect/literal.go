@@ -0,0 +1,75 @@
+package dialect
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// IsNumericTypeName reports whether the database type name (in upper case)
+// is a numeric type.
+func IsNumericTypeName(name string) bool {
+	name = baseTypeName(name)
+	if name == "YEAR" {
+		return true
+	}
+	switch typeKinds[name] {
+	case KindInteger, KindFloat:
+		return true
+	case KindDecimal:
+		return !strings.Contains(name, "MONEY")
+	}
+	return false
+}
+
+// QuoteString returns the string literal of s.
+func (D *Entry) QuoteString(s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	if D.Syntax.BackslashEscape {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + s + "'"
+}
+
+// QuoteLiteral returns the SQL literal of the value scanned from a row.
+// LiteralFor of the dialect is tried first.
+func (D *Entry) QuoteLiteral(v any) string {
+	if f := D.LiteralFor; f != nil {
+		if s, ok := f(v); ok {
+			return s
+		}
+	}
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(x)
+	case float32:
+		return D.QuoteLiteral(float64(x))
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return D.QuoteString(strconv.FormatFloat(x, 'g', -1, 64))
+		}
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	case time.Time:
+		return D.QuoteString(x.Format(DateTimeLayout))
+	case []byte:
+		if utf8.Valid(x) {
+			return D.QuoteString(string(x))
+		}
+		return "X'" + strings.ToUpper(hex.EncodeToString(x)) + "'"
+	case string:
+		return D.QuoteString(x)
+	default:
+		return D.QuoteString(fmt.Sprint(x))
+	}
+}
@@ -1,6 +1,7 @@
 package dialect
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -15,6 +16,11 @@ var (
 	ErrTooFewArguments         = errors.New("too few arguments")
 	ErrDSNStringIsNotSpecified = errors.New("DSN String is not specified")
 	ErrSupportDriveNotFound    = errors.New("support driver not found")
+	ErrNotSupported            = errors.New("not supported")
+
+	// ErrSessionNotRestored tells that the state of the session changed
+	// temporarily could not be restored, so the connection must be closed.
+	ErrSessionNotRestored = errors.New("the state of the session could not be restored")
 )
 
 type PlaceHolder interface {
@@ -32,6 +38,29 @@ type Entry struct {
 	// SQLForTables is the SQL query used to retrieve table information.
 	SQLForTables string
 
+	// SQLForPrimaryKey is the SQL query used to retrieve the columns of the
+	// primary key of the table given as the first parameter in order.
+	SQLForPrimaryKey string
+
+	// SQLForForeignKeys is the SQL query used to retrieve the foreign keys of
+	// the table given as the first parameter. Each row has the name of the
+	// constraint, the column, the referenced table and the referenced column.
+	SQLForForeignKeys string
+
+	// SQLForIndexes is the SQL query used to retrieve the indexes of the table
+	// given as the first parameter except those of the constraints. Each row
+	// has the name of the index, whether it is unique and the column.
+	SQLForIndexes string
+
+	// SQLForUniqueKeys is the SQL query used to retrieve the unique
+	// constraints of the table given as the first parameter. Each row has
+	// the name of the constraint and the column.
+	SQLForUniqueKeys string
+
+	// AlterTableAddConstraint is true when the foreign keys can be added to
+	// existing tables with ALTER TABLE ... ADD CONSTRAINT.
+	AlterTableAddConstraint bool
+
 	// TableNameField is the field name for table names in SQL results.
 	TableNameField string
 
@@ -41,6 +70,13 @@ type Entry struct {
 	// PlaceHolder defines how to represent placeholders (e.g., ?, $1) in SQL.
 	PlaceHolder PlaceHolder
 
+	// QuestionMarkParameter is true when `?` is the parameter marker of the
+	// database, so that `?` in statements can be bound to the values asked.
+	QuestionMarkParameter bool
+
+	// MultiRowValues is true when INSERT accepts VALUES of multiple rows.
+	MultiRowValues bool
+
 	// TypeConverterFor returns a converter function for a given type name.
 	// The returned function converts a string literal to the corresponding Go value.
 	TypeConverterFor func(typeName string) func(literal string) (any, error)
@@ -56,6 +92,41 @@ type Entry struct {
 
 	// IdentifierEncloser encloses an identifier with dialect-specific quotes.
 	IdentifierEncloser func(name string) string
+
+	// AbortsTransactionOnError is true when the transaction can not be used
+	// after an error until it is rolled back to a savepoint.
+	AbortsTransactionOnError bool
+
+	// SQLForTimeout returns the statement to set the timeout of statements
+	// on the server side. A zero duration means no timeout. When it returns
+	// an empty string, nothing is executed.
+	SQLForTimeout func(d time.Duration) string
+
+	// SQLForReadOnly is the statement to make the session read-only on the
+	// server. It is empty when the database has no such statement.
+	SQLForReadOnly string
+
+	// SQLForReadOnlyTransaction is the statement to make the transaction
+	// read-only, executed at the beginning of each transaction of read-only
+	// connections when SQLForReadOnly is not available.
+	SQLForReadOnlyTransaction string
+
+	// LiteralFor returns the SQL literal of the value for the types which
+	// need dialect-specific notations such as timestamps and binaries.
+	// When it returns false, the common notation is used.
+	LiteralFor func(v any) (string, bool)
+
+	// TypeNameFor returns the name of the type for the columns copied from
+	// another database when the common name such as BIGINT or TEXT is not
+	// available. When it returns false, the common name is used.
+	TypeNameFor func(spec ColumnTypeSpec) (string, bool)
+
+	// Syntax is the lexical rules of quotes and comments used to split
+	// statements and to find keywords.
+	Syntax Syntax
+
+	// PlanFor retrieves the execution plan of the given statement without executing it.
+	PlanFor func(ctx context.Context, conn CanQueryAndExec, statement string) (*Plan, error)
 }
 
 // EncloseIdentifier returns the given name enclosed with
@@ -68,6 +139,17 @@ func (D *Entry) EncloseIdentifier(name string) string {
 	return `"` + name + `"`
 }
 
+var rxNonQuote = regexp.MustCompile(`^\w+$`)
+
+// EncloseIdentifierIfNeed returns the name as it is when it consists of
+// word characters only, otherwise enclosed with EncloseIdentifier.
+func (D *Entry) EncloseIdentifierIfNeed(name string) string {
+	if rxNonQuote.MatchString(name) {
+		return name
+	}
+	return D.EncloseIdentifier(name)
+}
+
 func (D *Entry) LookupConverter(typeName string) func(string) (any, error) {
 	if D.TypeConverterFor == nil {
 		return nil
@@ -160,6 +242,7 @@ type DBInfo struct {
 	Driver     string
 	DataSource string
 	Dialect    *Entry
+	secrets    []string
 }
 
 func ReadDBInfoFromArgs(args []string) (*DBInfo, error) {
@@ -167,10 +250,16 @@ func ReadDBInfoFromArgs(args []string) (*DBInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	resolver := &secretResolver{}
+	dsn, err := resolver.resolve(args[1])
+	if err != nil {
+		return nil, err
+	}
 	d := &DBInfo{
 		Driver:     args[0],
-		DataSource: args[1],
+		DataSource: dsn,
 		Dialect:    entry,
+		secrets:    resolver.secrets,
 	}
 	if entry.DSNFilter != nil {
 		if d.DataSource, err = entry.DSNFilter(d.DataSource); err != nil {
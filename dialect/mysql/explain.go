@@ -0,0 +1,26 @@
+package sqlbless
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func planLabel(name string, object map[string]any) string {
+	if table, ok := object["table_name"]; ok {
+		if access, ok := object["access_type"]; ok {
+			return fmt.Sprintf("%s %v (%v)", name, table, access)
+		}
+		return fmt.Sprintf("%s %v", name, table)
+	}
+	return name
+}
+
+func explain(ctx context.Context, conn dialect.CanQueryAndExec, statement string) (*dialect.Plan, error) {
+	source, err := dialect.QueryString(ctx, conn, "EXPLAIN FORMAT=JSON "+statement)
+	if err != nil {
+		return nil, err
+	}
+	return dialect.PlanFromJSON(source, planLabel)
+}
@@ -0,0 +1,75 @@
+package sqlbless
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+const sqlForPlanTable = `
+  select id, parent_id, operation, options, object_name, cost, cardinality
+    from plan_table
+   where statement_id = :1
+     and plan_id = ( select max(plan_id) from plan_table where statement_id = :2 )
+   order by id`
+
+const sqlForDeletePlan = `delete from plan_table where statement_id = :1`
+
+// explain reads PLAN_TABLE as DBMS_XPLAN.DISPLAY does, to make the tree
+// from the columns of the rows, and deletes the rows afterwards.
+func explain(ctx context.Context, conn dialect.CanQueryAndExec, statement string) (plan *dialect.Plan, err error) {
+	statementID := fmt.Sprintf("SQLBLESS%d", time.Now().UnixNano())
+	_, err = conn.ExecContext(ctx,
+		fmt.Sprintf("EXPLAIN PLAN SET STATEMENT_ID = '%s' FOR %s", statementID, statement))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// Deleted even when cancelled.
+		if _, err1 := conn.ExecContext(context.Background(), sqlForDeletePlan, statementID); err1 != nil {
+			err = errors.Join(err, fmt.Errorf("plan_table: %w", err1))
+		}
+	}()
+	return readPlanTable(ctx, conn, statementID)
+}
+
+func readPlanTable(ctx context.Context, conn dialect.CanQuery, statementID string) (*dialect.Plan, error) {
+	rows, err := conn.QueryContext(ctx, sqlForPlanTable, statementID, statementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var planRows []*dialect.PlanRow
+	for rows.Next() {
+		var id, parentID sql.NullInt64
+		var operation, options, objectName, cost, cardinality sql.NullString
+		if err := rows.Scan(&id, &parentID, &operation, &options, &objectName, &cost, &cardinality); err != nil {
+			return nil, err
+		}
+		op := strings.TrimSpace(operation.String + " " + options.String)
+		if objectName.Valid {
+			op = op + " " + objectName.String
+		}
+		if !parentID.Valid {
+			parentID.Int64 = -1
+		}
+		planRows = append(planRows, &dialect.PlanRow{
+			ID:       id.Int64,
+			ParentID: parentID.Int64,
+			PlanNode: dialect.PlanNode{
+				Operation: op,
+				Details:   []string{cost.String, cardinality.String},
+			},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return dialect.BuildPlanFromRows([]string{"COST", "ROWS"}, planRows), nil
+}
@@ -1,7 +1,10 @@
 package sqlbless
 
 import (
+	"encoding/hex"
+	"fmt"
 	"strings"
+	"time"
 
 	_ "github.com/sijms/go-ora/v2"
 
@@ -17,12 +20,65 @@ func oracleTypeNameToConv(typeName string) func(string) (any, error) {
 	return nil
 }
 
+// oracleTypeName maps the types which Oracle does not have.
+func oracleTypeName(spec dialect.ColumnTypeSpec) (string, bool) {
+	switch spec.Kind {
+	case dialect.KindInteger:
+		return "NUMBER(19)", true
+	case dialect.KindDecimal:
+		if spec.Precision > 0 {
+			return fmt.Sprintf("NUMBER(%d,%d)", spec.Precision, spec.Scale), true
+		}
+		return "NUMBER", true
+	case dialect.KindBool:
+		return "NUMBER(1)", true
+	case dialect.KindText:
+		if spec.Length > 0 && spec.Length <= 4000 {
+			return fmt.Sprintf("VARCHAR2(%d)", spec.Length), true
+		}
+		return "CLOB", true
+	}
+	return "", false
+}
+
+// oracleLiteral writes timestamps as ANSI literals, binaries with HEXTORAW
+// and booleans as numbers.
+func oracleLiteral(v any) (string, bool) {
+	switch x := v.(type) {
+	case time.Time:
+		return "TIMESTAMP '" + x.Format(dialect.DateTimeLayout) + "'", true
+	case []byte:
+		return "HEXTORAW('" + strings.ToUpper(hex.EncodeToString(x)) + "')", true
+	case bool:
+		if x {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
+
+// oracleDSNFilter converts the key=value string to the URL of go-ora.
+func oracleDSNFilter(dsn string) (string, error) {
+	if !dialect.IsKeyValue(dsn) {
+		return dsn, nil
+	}
+	d, err := dialect.ParseKeyValue(dsn)
+	if err != nil {
+		return "", err
+	}
+	d.Scheme = "oracle"
+	return d.URL(), nil
+}
+
 var oracleSpec = &dialect.Entry{
 	Usage: "sqlbless oracle://<USERNAME>:<PASSWORD>@<HOSTNAME>:<PORT>/<SERVICE>",
 	SQLForColumns: `
   select column_id as "ID",
 		 column_name as "NAME",
 		 case 
+		   when data_type = 'NUMBER' and data_precision is not null then
+		     data_type || '(' || data_precision || ',' || data_scale || ')'
 		   when data_type = 'NUMBER' then data_type
 		   when data_type = 'DATE' then data_type
 		   when data_type like 'TIMESTAMP%' then data_type
@@ -35,11 +91,48 @@ var oracleSpec = &dialect.Entry{
 	from all_tab_columns
    where table_name = UPPER(:1)
    order by column_id`,
-	SQLForTables:     `select * from tab where tname not like 'BIN$%'`,
-	TypeConverterFor: oracleTypeNameToConv,
-	TableNameField:   "tname",
-	ColumnNameField:  "name",
-	PlaceHolder:      &dialect.PlaceHolderName{Prefix: ":", Format: "v"},
+	SQLForPrimaryKey: `
+  select cc.column_name
+	from user_constraints c
+	join user_cons_columns cc on cc.constraint_name = c.constraint_name
+   where c.constraint_type = 'P'
+	 and c.table_name = UPPER(:1)
+   order by cc.position`,
+	SQLForForeignKeys: `
+  select c.constraint_name, cc.column_name, rc.table_name, rcc.column_name
+	from user_constraints c
+	join user_cons_columns cc on cc.constraint_name = c.constraint_name
+	join user_constraints rc on rc.constraint_name = c.r_constraint_name
+	join user_cons_columns rcc on rcc.constraint_name = rc.constraint_name and rcc.position = cc.position
+   where c.constraint_type = 'R'
+	 and c.table_name = UPPER(:1)
+   order by c.constraint_name, cc.position`,
+	SQLForIndexes: `
+  select i.index_name, case i.uniqueness when 'UNIQUE' then 1 else 0 end, ic.column_name
+	from user_indexes i
+	join user_ind_columns ic on ic.index_name = i.index_name
+   where i.table_name = UPPER(:1)
+	 and not exists (select 1 from user_constraints c where c.index_name = i.index_name)
+   order by i.index_name, ic.column_position`,
+	SQLForUniqueKeys: `
+  select c.constraint_name, cc.column_name
+	from user_constraints c
+	join user_cons_columns cc on cc.constraint_name = c.constraint_name
+   where c.constraint_type = 'U'
+	 and c.table_name = UPPER(:1)
+   order by c.constraint_name, cc.position`,
+	AlterTableAddConstraint:   true,
+	SQLForTables:              `select * from tab where tname not like 'BIN$%'`,
+	TypeConverterFor:          oracleTypeNameToConv,
+	TableNameField:            "tname",
+	ColumnNameField:           "name",
+	PlaceHolder:               &dialect.PlaceHolderName{Prefix: ":", Format: "v"},
+	DSNFilter:                 oracleDSNFilter,
+	PlanFor:                   explain,
+	SQLForReadOnlyTransaction: "SET TRANSACTION READ ONLY",
+	LiteralFor:                oracleLiteral,
+	TypeNameFor:               oracleTypeName,
+	Syntax:                    dialect.Syntax{SlashTerminatesBlocks: true},
 }
 
 func init() {
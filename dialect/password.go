@@ -0,0 +1,222 @@
+package dialect
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-tty"
+)
+
+var ErrPasswordUnavailable = errors.New("the password for the DSN is not available")
+
+// PasswordFile is the file to read the password for the placeholders of
+// DSNs from instead of asking it on the terminal.
+var PasswordFile string
+
+// AskPassword reads the password for the placeholders of DSNs. It shows
+// the input masked on the terminal by default.
+var AskPassword = askPasswordOnTty
+
+func askPasswordOnTty(prompt string) (string, error) {
+	t, err := tty.Open()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrPasswordUnavailable, err.Error())
+	}
+	defer t.Close()
+	fmt.Fprint(t.Output(), prompt)
+	return t.ReadPassword()
+}
+
+const redactedPassword = "****"
+
+var (
+	// rxURLPassword matches the password of `scheme://user:?@host` and
+	// `user:${NAME}@tcp(host)` of MySQL.
+	rxURLPassword = regexp.MustCompile(`^((?:[A-Za-z][\w+.-]*://)?[^/@:\s]*:)(\?|\$\{\w+\})@`)
+
+	// rxKeyPassword matches the password of `password=?` of libpq and
+	// `Password=${NAME};` of SQL Server.
+	rxKeyPassword = regexp.MustCompile(`(?i)((?:^|[\s;])(?:password|pwd)=)(\?|\$\{\w+\})`)
+
+	// rxEnvReference matches `${NAME}` in the other places.
+	rxEnvReference = regexp.MustCompile(`\$\{\w+\}`)
+
+	// rxPlainPassword matches the passwords written in DSNs as they are.
+	// The password of a URL lasts until the last `@` before the host.
+	rxPlainPassword = regexp.MustCompile(`(?i)^((?:[A-Za-z][\w+.-]*://)?[^/@:\s]*:)([^/\s]*)@|((?:^|[\s;])(?:password|pwd)=)('[^']*'|[^\s;]*)`)
+)
+
+// secretResolver replaces the placeholders of a DSN with the passwords
+// and remembers the inserted texts to redact them.
+type secretResolver struct {
+	password *string
+	secrets  []string
+}
+
+// value returns the password for the placeholder. `${NAME}` is the
+// environment variable NAME. `?` and the variables not set are read
+// from PasswordFile or asked on the terminal once for the DSN.
+func (r *secretResolver) value(placeholder string) (string, error) {
+	if strings.HasPrefix(placeholder, "${") {
+		if value, ok := os.LookupEnv(placeholder[2 : len(placeholder)-1]); ok {
+			return value, nil
+		}
+	}
+	if r.password != nil {
+		return *r.password, nil
+	}
+	var password string
+	if PasswordFile != "" {
+		data, err := os.ReadFile(PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrPasswordUnavailable, err.Error())
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	} else {
+		var err error
+		if password, err = AskPassword("Password: "); err != nil {
+			return "", err
+		}
+	}
+	r.password = &password
+	return password, nil
+}
+
+func (r *secretResolver) replace(rx *regexp.Regexp, dsn string, escape func(string) string) (string, error) {
+	var err error
+	dsn = rx.ReplaceAllStringFunc(dsn, func(m string) string {
+		sub := rx.FindStringSubmatch(m)
+		prefix, placeholder, suffix := "", m, ""
+		if len(sub) >= 3 {
+			prefix, placeholder = sub[1], sub[2]
+			suffix = m[len(prefix)+len(placeholder):]
+		}
+		value, err1 := r.value(placeholder)
+		if err1 != nil {
+			err = err1
+			return m
+		}
+		// The raw one is also redacted since DSNFilter may unescape it.
+		if value != "" {
+			r.secrets = append(r.secrets, escape(value), value)
+		}
+		return prefix + escape(value) + suffix
+	})
+	return dsn, err
+}
+
+// escapeUserInfo escapes the password for the user information of URLs.
+func escapeUserInfo(password string) string {
+	return strings.TrimPrefix(url.UserPassword("", password).String(), ":")
+}
+
+// quoteKeyValue encloses the value with quotations for key=value style
+// DSNs when it contains spaces, quotations or semicolons.
+func quoteKeyValue(password string) string {
+	if !strings.ContainsAny(password, " \t'\\;\"") {
+		return password
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(password) + "'"
+}
+
+func rawValue(value string) string { return value }
+
+// resolve replaces the placeholders of the password in dsn.
+func (r *secretResolver) resolve(dsn string) (string, error) {
+	dsn, err := r.replace(rxURLPassword, dsn, escapeUserInfo)
+	if err != nil {
+		return "", err
+	}
+	if dsn, err = r.replace(rxKeyPassword, dsn, quoteKeyValue); err != nil {
+		return "", err
+	}
+	return r.replace(rxEnvReference, dsn, rawValue)
+}
+
+// Redact replaces the passwords resolved for the placeholders in s such
+// as error messages with `****`.
+func (d *DBInfo) Redact(s string) string {
+	for _, secret := range d.secrets {
+		s = strings.ReplaceAll(s, secret, redactedPassword)
+	}
+	return s
+}
+
+// RedactedDataSource returns the DSN to be shown, whose passwords are
+// replaced with `****` including the ones written as they are.
+func (d *DBInfo) RedactedDataSource() string {
+	return RedactDataSource(d.Redact(d.DataSource))
+}
+
+func isPlaceholder(s string) bool {
+	return s == "?" || (s != "" && rxEnvReference.FindString(s) == s)
+}
+
+// RedactDataSource returns the DSN whose passwords written as they are
+// are replaced with `****`. The placeholders of the passwords are kept.
+func RedactDataSource(dsn string) string {
+	return rxPlainPassword.ReplaceAllStringFunc(dsn, func(m string) string {
+		sub := rxPlainPassword.FindStringSubmatch(m)
+		if sub[1] != "" {
+			if isPlaceholder(sub[2]) {
+				return m
+			}
+			return sub[1] + redactedPassword + "@"
+		}
+		if isPlaceholder(sub[4]) {
+			return m
+		}
+		return sub[3] + redactedPassword
+	})
+}
+
+// plainPasswords returns the passwords written in the DSN and their
+// unescaped forms.
+func plainPasswords(dsn string) []string {
+	var result []string
+	for _, sub := range rxPlainPassword.FindAllStringSubmatch(dsn, -1) {
+		password := sub[2]
+		if sub[1] == "" {
+			password = sub[4]
+		}
+		if password == "" {
+			continue
+		}
+		result = append(result, password)
+		if raw, err := url.PathUnescape(password); err == nil && raw != password {
+			result = append(result, raw)
+		}
+		if raw := strings.Trim(password, "'"); raw != password && raw != "" {
+			result = append(result, raw)
+		}
+	}
+	return result
+}
+
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string { return e.message }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// RedactError returns the error whose message has the passwords of the
+// DSN replaced with `****`. It wraps err for errors.Is and errors.As.
+func RedactError(dsn string, err error) error {
+	if err == nil {
+		return nil
+	}
+	message := strings.ReplaceAll(err.Error(), dsn, RedactDataSource(dsn))
+	for _, password := range plainPasswords(dsn) {
+		message = strings.ReplaceAll(message, password, redactedPassword)
+	}
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, message: message}
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+// planConditions are the members of a plan node shown in the DETAIL column.
+var planConditions = []string{
+	"Index Cond",
+	"Recheck Cond",
+	"Hash Cond",
+	"Merge Cond",
+	"Join Filter",
+	"Filter",
+	"Sort Key",
+	"Group Key",
+}
+
+func toPlanNode(m map[string]any) *dialect.PlanNode {
+	var op strings.Builder
+	fmt.Fprint(&op, m["Node Type"])
+	if v, ok := m["Join Type"]; ok {
+		fmt.Fprintf(&op, " (%v)", v)
+	}
+	if v, ok := m["Index Name"]; ok {
+		fmt.Fprintf(&op, " using %v", v)
+	}
+	if v, ok := m["Relation Name"]; ok {
+		fmt.Fprintf(&op, " on %v", v)
+		if alias, ok := m["Alias"]; ok && alias != v {
+			fmt.Fprintf(&op, " %v", alias)
+		}
+	}
+	var details []string
+	for _, key := range planConditions {
+		if v, ok := m[key]; ok {
+			details = append(details, fmt.Sprintf("%s: %v", key, v))
+		}
+	}
+	node := &dialect.PlanNode{
+		Operation: op.String(),
+		Details: []string{
+			fmt.Sprintf("%v..%v", m["Startup Cost"], m["Total Cost"]),
+			fmt.Sprint(m["Plan Rows"]),
+			strings.Join(details, ", "),
+		},
+	}
+	if children, ok := m["Plans"].([]any); ok {
+		for _, c := range children {
+			if child, ok := c.(map[string]any); ok {
+				node.Children = append(node.Children, toPlanNode(child))
+			}
+		}
+	}
+	return node
+}
+
+func explain(ctx context.Context, conn dialect.CanQueryAndExec, statement string) (*dialect.Plan, error) {
+	source, err := dialect.QueryString(ctx, conn, "EXPLAIN (FORMAT JSON) "+statement)
+	if err != nil {
+		return nil, err
+	}
+	var doc []struct {
+		Plan map[string]any `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(source), &doc); err != nil {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+	plan := &dialect.Plan{Columns: []string{"COST", "ROWS", "DETAIL"}}
+	for _, d := range doc {
+		if d.Plan != nil {
+			plan.Roots = append(plan.Roots, toPlanNode(d.Plan))
+		}
+	}
+	return plan, nil
+}
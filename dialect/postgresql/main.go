@@ -1,8 +1,11 @@
 package postgres
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	_ "github.com/lib/pq"
 
@@ -42,16 +45,25 @@ func (ph *placeHolder) Values() (result []any) {
 	return
 }
 
+// postgresDSNFilter converts `postgres://` URLs to the key=value string,
+// which is the native form of libpq.
+func postgresDSNFilter(dsn string) (string, error) {
+	if !dialect.IsURL(dsn) {
+		return dsn, nil
+	}
+	d, err := dialect.ParseURL(dsn)
+	if err != nil {
+		return "", err
+	}
+	return d.KeyValue(), nil
+}
+
 var postgresSpec = &dialect.Entry{
 	Usage: "sqlbless postgres://<USERNAME>:<PASSWORD>@<HOSTNAME>:<PORT>/<DBNAME>?sslmode=disable",
 	SQLForColumns: `
       select a.attnum as "ID",
              a.attname as "NAME",
-             case
-               when t.typname = 'varchar' then 'varchar(' || ( a.atttypmod - 4 )  || ')'
-               when a.atttypmod >= 0 then t.typname || '(' || a.atttypmod || ')'
-               else t.typname
-             end as "TYPE",
+             format_type(a.atttypid, a.atttypmod) as "TYPE",
              case
                when a.attnotnull then 'NOT NULL'
                else 'NULL'
@@ -63,6 +75,46 @@ var postgresSpec = &dialect.Entry{
          and t.oid = a.atttypid
          and a.attisdropped is false
        order by a.attnum`,
+	SQLForPrimaryKey: `
+      select a.attname
+        from pg_index i
+        join pg_class c on c.oid = i.indrelid
+        cross join lateral unnest(i.indkey::smallint[]) with ordinality as k(attnum, n)
+        join pg_attribute a on a.attrelid = c.oid and a.attnum = k.attnum
+       where c.relname = $1
+         and i.indisprimary
+       order by k.n`,
+	SQLForForeignKeys: `
+      select con.conname, a.attname, fc.relname, fa.attname
+        from pg_constraint con
+        join pg_class c on c.oid = con.conrelid
+        join pg_class fc on fc.oid = con.confrelid
+        cross join lateral unnest(con.conkey, con.confkey) with ordinality as k(attnum, fattnum, n)
+        join pg_attribute a on a.attrelid = con.conrelid and a.attnum = k.attnum
+        join pg_attribute fa on fa.attrelid = con.confrelid and fa.attnum = k.fattnum
+       where con.contype = 'f'
+         and c.relname = $1
+       order by con.conname, k.n`,
+	SQLForIndexes: `
+      select ic.relname, i.indisunique, a.attname
+        from pg_index i
+        join pg_class c on c.oid = i.indrelid
+        join pg_class ic on ic.oid = i.indexrelid
+        cross join lateral unnest(i.indkey::smallint[]) with ordinality as k(attnum, n)
+        join pg_attribute a on a.attrelid = c.oid and a.attnum = k.attnum
+       where c.relname = $1
+         and not exists (select 1 from pg_constraint con where con.conindid = i.indexrelid)
+       order by ic.relname, k.n`,
+	SQLForUniqueKeys: `
+      select con.conname, a.attname
+        from pg_constraint con
+        join pg_class c on c.oid = con.conrelid
+        cross join lateral unnest(con.conkey) with ordinality as k(attnum, n)
+        join pg_attribute a on a.attrelid = con.conrelid and a.attnum = k.attnum
+       where con.contype = 'u'
+         and c.relname = $1
+       order by con.conname, k.n`,
+	AlterTableAddConstraint: true,
 	SQLForTables: `
       select *
         from information_schema.tables
@@ -70,9 +122,50 @@ var postgresSpec = &dialect.Entry{
          and table_schema not in ('pg_catalog', 'information_schema')`,
 	TypeConverterFor:  postgresTypeNameToConv,
 	PlaceHolder:       &placeHolder{},
+	MultiRowValues:    true,
+	DSNFilter:         postgresDSNFilter,
 	TableNameField:    "table_name",
 	ColumnNameField:   "name",
 	IsTransactionSafe: canUseInTransaction,
+	PlanFor:           explain,
+	SQLForTimeout:     sqlForTimeout,
+	SQLForReadOnly:    "SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY",
+	LiteralFor:        postgresLiteral,
+	TypeNameFor:       postgresTypeName,
+
+	AbortsTransactionOnError: true,
+	Syntax: dialect.Syntax{
+		DollarQuote:   true,
+		EscapeString:  true,
+		NestedComment: true,
+	},
+}
+
+// postgresTypeName maps binaries to bytea.
+func postgresTypeName(spec dialect.ColumnTypeSpec) (string, bool) {
+	if spec.Kind == dialect.KindBinary {
+		return "BYTEA", true
+	}
+	return "", false
+}
+
+// postgresLiteral keeps the time zone of timestamps and writes binaries
+// in the hex format of bytea.
+func postgresLiteral(v any) (string, bool) {
+	switch x := v.(type) {
+	case time.Time:
+		return "'" + x.Format(dialect.DateTimeTzLayout) + "'", true
+	case []byte:
+		if !utf8.Valid(x) {
+			return `'\x` + hex.EncodeToString(x) + "'::bytea", true
+		}
+	}
+	return "", false
+}
+
+// sqlForTimeout sets statement_timeout in milliseconds. Zero disables it.
+func sqlForTimeout(d time.Duration) string {
+	return fmt.Sprintf("SET statement_timeout = %d", d.Milliseconds())
 }
 
 func canUseInTransaction(sql string) bool {
@@ -88,3 +88,182 @@ func (e *Entry) FetchTables(ctx context.Context, conn CanQuery) ([]string, error
 func (e *Entry) FetchColumns(ctx context.Context, conn CanQuery, table string) ([]string, error) {
 	return queryOneColumn(ctx, conn, e.BuildSQLForColumns(table), e.ColumnNameField, table)
 }
+
+// Column is the definition of a column read from the catalog.
+type Column struct {
+	Name    string
+	Type    string
+	NotNull bool
+}
+
+// ForeignKey is a foreign key constraint. Name is empty when the database
+// does not name it.
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+}
+
+// UniqueKey is a unique constraint.
+type UniqueKey struct {
+	Name    string
+	Columns []string
+}
+
+// Index is an index which is not created by a constraint.
+type Index struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+// Constraints are the primary key, the unique keys, the foreign keys and
+// the indexes of a table.
+type Constraints struct {
+	PrimaryKey  []string
+	UniqueKeys  []UniqueKey
+	ForeignKeys []ForeignKey
+	Indexes     []Index
+}
+
+func queryStrings(ctx context.Context, conn CanQuery, sqlStr string, width int, args ...any) ([][]string, error) {
+	rows, err := conn.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	values := make([]sql.NullString, width)
+	refs := make([]any, width)
+	for i := range values {
+		refs[i] = &values[i]
+	}
+	var result [][]string
+	for rows.Next() {
+		if err := rows.Scan(refs...); err != nil {
+			return nil, err
+		}
+		record := make([]string, width)
+		for i, v := range values {
+			record[i] = v.String
+		}
+		result = append(result, record)
+	}
+	return result, rows.Err()
+}
+
+// FetchColumnDefinitions reads the names, the types and the nullability of
+// the columns of the table with the SQL of SQLForColumns. The type is read
+// from the field TYPE and the nullability from the field "NULL?" or notnull.
+func (e *Entry) FetchColumnDefinitions(ctx context.Context, conn CanQuery, table string) ([]Column, error) {
+	rows, err := conn.QueryContext(ctx, e.BuildSQLForColumns(table), table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	namePosition := findColumn(e.ColumnNameField, columns)
+	if namePosition < 0 {
+		return nil, fmt.Errorf("%s: %w", e.ColumnNameField, ErrColumnNameNotFound)
+	}
+	typePosition := findColumn("TYPE", columns)
+	nullPosition := findColumn("NULL?", columns)
+	notNullPosition := findColumn("notnull", columns)
+
+	values := make([]sql.NullString, len(columns))
+	refs := make([]any, len(columns))
+	for i := range values {
+		refs[i] = &values[i]
+	}
+	var result []Column
+	for rows.Next() {
+		if err := rows.Scan(refs...); err != nil {
+			return nil, err
+		}
+		c := Column{Name: values[namePosition].String}
+		if typePosition >= 0 {
+			c.Type = strings.TrimSpace(values[typePosition].String)
+		}
+		if nullPosition >= 0 {
+			c.NotNull = strings.EqualFold(values[nullPosition].String, "NOT NULL")
+		} else if notNullPosition >= 0 {
+			c.NotNull = values[notNullPosition].String == "1"
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+func isTrue(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "t", "true", "y", "yes":
+		return true
+	}
+	return false
+}
+
+// FetchConstraints reads the primary key, the unique keys, the foreign keys
+// and the indexes of the table. It returns ErrNotSupported when the dialect has no SQL for them.
+func (e *Entry) FetchConstraints(ctx context.Context, conn CanQuery, table string) (*Constraints, error) {
+	if e.SQLForPrimaryKey == "" {
+		return nil, ErrNotSupported
+	}
+	result := &Constraints{}
+	pk, err := queryStrings(ctx, conn, e.SQLForPrimaryKey, 1, table)
+	if err != nil {
+		return nil, fmt.Errorf("primary key: %w", err)
+	}
+	for _, r := range pk {
+		result.PrimaryKey = append(result.PrimaryKey, r[0])
+	}
+	if e.SQLForUniqueKeys != "" {
+		uks, err := queryStrings(ctx, conn, e.SQLForUniqueKeys, 2, table)
+		if err != nil {
+			return nil, fmt.Errorf("unique keys: %w", err)
+		}
+		for i, r := range uks {
+			n := len(result.UniqueKeys)
+			if i == 0 || r[0] != uks[i-1][0] {
+				result.UniqueKeys = append(result.UniqueKeys, UniqueKey{Name: r[0]})
+				n++
+			}
+			uk := &result.UniqueKeys[n-1]
+			uk.Columns = append(uk.Columns, r[1])
+		}
+	}
+	if e.SQLForForeignKeys != "" {
+		fks, err := queryStrings(ctx, conn, e.SQLForForeignKeys, 4, table)
+		if err != nil {
+			return nil, fmt.Errorf("foreign keys: %w", err)
+		}
+		for i, r := range fks {
+			n := len(result.ForeignKeys)
+			if i == 0 || r[0] != fks[i-1][0] || r[2] != fks[i-1][2] {
+				result.ForeignKeys = append(result.ForeignKeys, ForeignKey{Name: r[0], RefTable: r[2]})
+				n++
+			}
+			fk := &result.ForeignKeys[n-1]
+			fk.Columns = append(fk.Columns, r[1])
+			fk.RefColumns = append(fk.RefColumns, r[3])
+		}
+	}
+	if e.SQLForIndexes != "" {
+		indexes, err := queryStrings(ctx, conn, e.SQLForIndexes, 3, table)
+		if err != nil {
+			return nil, fmt.Errorf("indexes: %w", err)
+		}
+		for i, r := range indexes {
+			n := len(result.Indexes)
+			if i == 0 || r[0] != indexes[i-1][0] {
+				result.Indexes = append(result.Indexes, Index{Name: r[0], Unique: isTrue(r[1])})
+				n++
+			}
+			index := &result.Indexes[n-1]
+			index.Columns = append(index.Columns, r[2])
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func explain(ctx context.Context, conn dialect.CanQueryAndExec, statement string) (*dialect.Plan, error) {
+	rows, err := conn.QueryContext(ctx, "EXPLAIN QUERY PLAN "+statement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var planRows []*dialect.PlanRow
+	for rows.Next() {
+		var id, parent, notUsed sql.NullInt64
+		var detail sql.NullString
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return nil, err
+		}
+		planRows = append(planRows, &dialect.PlanRow{
+			ID:       id.Int64,
+			ParentID: parent.Int64,
+			PlanNode: dialect.PlanNode{Operation: detail.String},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return dialect.BuildPlanFromRows(nil, planRows), nil
+}
@@ -3,7 +3,9 @@ package sqlite
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"strings"
+	"time"
 
 	_ "github.com/glebarez/go-sqlite/compat"
 
@@ -11,6 +13,32 @@ import (
 	"github.com/hymkor/sqlbless/internal/misc"
 )
 
+// dsnFilter converts `sqlite3:///path/file.db` and `sqlite3::memory:` to
+// the path of the file for the driver.
+func dsnFilter(dsn string) (string, error) {
+	const scheme = "sqlite3:"
+	if len(dsn) < len(scheme) || !strings.EqualFold(dsn[:len(scheme)], scheme) {
+		return dsn, nil
+	}
+	dsn = dsn[len(scheme):]
+	if !strings.HasPrefix(dsn, "//") {
+		return dsn, nil
+	}
+	path, opts, hasOpts := strings.Cut(dsn[2:], "?")
+	path, err := url.PathUnescape(path)
+	if err != nil {
+		return "", err
+	}
+	// sqlite3:///C:/path/file.db on Windows
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	if hasOpts {
+		path += "?" + opts
+	}
+	return path, nil
+}
+
 var Entry = &dialect.Entry{
 	Usage: "sqlbless sqlite3 :memory: OR <FILEPATH>",
 	SQLForTables: `
@@ -19,16 +47,55 @@ var Entry = &dialect.Entry{
 	union all
 	select 'temp' as schema,name,rootpage,sql from sqlite_temp_master
 	where type = 'table'`,
-	TypeConverterFor:  typeNameToConv,
-	PlaceHolder:       &placeHolder{},
-	SQLForColumns:     `PRAGMA table_info({table_name})`,
+	TypeConverterFor:      typeNameToConv,
+	PlaceHolder:           &placeHolder{},
+	QuestionMarkParameter: true,
+	MultiRowValues:        true,
+	DSNFilter:             dsnFilter,
+	SQLForColumns:         `PRAGMA table_info({table_name})`,
+	SQLForPrimaryKey: `
+	select name from pragma_table_info(?) where pk > 0 order by pk`,
+	SQLForForeignKeys: `
+	with t(name) as (select ?)
+	select 'fk_' || t.name || '_' || f.id, f."from", f."table", f."to"
+	  from t, pragma_foreign_key_list(t.name) f
+	 order by f.id, f.seq`,
+	SQLForIndexes: `
+	with t(name) as (select ?)
+	select l.name, l."unique", i.name
+	  from t, pragma_index_list(t.name) l, pragma_index_info(l.name) i
+	 where l.origin = 'c'
+	 order by l.name, i.seqno`,
+	SQLForUniqueKeys: `
+	with t(name) as (select ?)
+	select 'uq_' || t.name || '_' || l.seq, i.name
+	  from t, pragma_index_list(t.name) l, pragma_index_info(l.name) i
+	 where l.origin = 'u'
+	 order by l.seq, i.seqno`,
 	TableNameField:    "name",
 	ColumnNameField:   "name",
 	IsTransactionSafe: canUseInTransaction,
+	PlanFor:           explain,
+	SQLForTimeout:     sqlForTimeout,
+	SQLForReadOnly:    "PRAGMA query_only = ON",
 	IsQuerySQL: func(s string) bool {
 		s, _ = misc.CutField(s)
 		return strings.EqualFold(s, "PRAGMA")
 	},
+	Syntax: dialect.Syntax{
+		Backtick: true,
+		Bracket:  true,
+	},
+}
+
+// sqlForTimeout makes SQLite wait for locks up to the timeout instead of
+// failing with SQLITE_BUSY at once. The busy timeout is left as it is
+// when no timeout is set.
+func sqlForTimeout(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("PRAGMA busy_timeout = %d", d.Milliseconds())
 }
 
 func canUseInTransaction(sql string) bool {
@@ -23,3 +23,26 @@ func TestCanUseInTransaction(t *testing.T) {
 		}
 	}
 }
+
+func TestDSNFilter(t *testing.T) {
+	tests := []struct {
+		dsn      string
+		expected string
+	}{
+		{":memory:", ":memory:"},
+		{"path/to/file.db", "path/to/file.db"},
+		{"sqlite3::memory:", ":memory:"},
+		{"sqlite3:///path/to/file.db", "/path/to/file.db"},
+		{"sqlite3:///C:/path/my%20file.db", "C:/path/my file.db"},
+		{"SQLITE3:///file.db?_pragma=foreign_keys(1)", "/file.db?_pragma=foreign_keys(1)"},
+	}
+	for _, tt := range tests {
+		result, err := dsnFilter(tt.dsn)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if result != tt.expected {
+			t.Errorf("SQLite3: dsn=%q, expected=%q, got=%q", tt.dsn, tt.expected, result)
+		}
+	}
+}
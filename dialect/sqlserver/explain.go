@@ -0,0 +1,107 @@
+package sqlserver
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// parseShowPlanXML makes a tree from the RelOp elements of SHOWPLAN_XML.
+func parseShowPlanXML(source string) (*dialect.Plan, error) {
+	plan := &dialect.Plan{Columns: []string{"COST", "ROWS", "DETAIL"}}
+	var stack []*dialect.PlanNode
+	dec := xml.NewDecoder(strings.NewReader(source))
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return plan, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("plan: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "StmtSimple":
+				if text := attr(t, "StatementText"); text != "" && len(stack) == 0 {
+					plan.Roots = append(plan.Roots, &dialect.PlanNode{
+						Operation: attr(t, "StatementType"),
+						Details: []string{
+							attr(t, "StatementSubTreeCost"),
+							attr(t, "StatementEstRows"),
+							strings.TrimSpace(text),
+						},
+					})
+				}
+			case "RelOp":
+				op := attr(t, "PhysicalOp")
+				if logical := attr(t, "LogicalOp"); logical != "" && logical != op {
+					op = fmt.Sprintf("%s (%s)", op, logical)
+				}
+				node := &dialect.PlanNode{
+					Operation: op,
+					Details: []string{
+						attr(t, "EstimatedTotalSubtreeCost"),
+						attr(t, "EstimateRows"),
+						"",
+					},
+				}
+				if len(stack) > 0 {
+					parent := stack[len(stack)-1]
+					parent.Children = append(parent.Children, node)
+				} else if n := len(plan.Roots); n > 0 {
+					plan.Roots[n-1].Children = append(plan.Roots[n-1].Children, node)
+				} else {
+					plan.Roots = append(plan.Roots, node)
+				}
+				stack = append(stack, node)
+			case "Object":
+				if len(stack) > 0 {
+					node := stack[len(stack)-1]
+					name := attr(t, "Table")
+					if index := attr(t, "Index"); index != "" {
+						name = name + "." + index
+					}
+					if name != "" && !strings.Contains(node.Operation, name) {
+						node.Operation = node.Operation + " " + name
+					}
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "RelOp" && len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+func explain(ctx context.Context, conn dialect.CanQueryAndExec, statement string) (*dialect.Plan, error) {
+	if _, err := conn.ExecContext(ctx, "SET SHOWPLAN_XML ON"); err != nil {
+		return nil, err
+	}
+	source, err := dialect.QueryString(ctx, conn, statement)
+
+	// Restored even when cancelled, since the statements after it would
+	// not be executed in the showplan mode.
+	if _, err1 := conn.ExecContext(context.Background(), "SET SHOWPLAN_XML OFF"); err1 != nil {
+		return nil, errors.Join(err, fmt.Errorf("SET SHOWPLAN_XML OFF: %w: %w", dialect.ErrSessionNotRestored, err1))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseShowPlanXML(source)
+}
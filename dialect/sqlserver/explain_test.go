@@ -0,0 +1,46 @@
+package sqlserver
+
+import (
+	"testing"
+)
+
+func TestParseShowPlanXML(t *testing.T) {
+	source := `<ShowPlanXML xmlns="http://schemas.microsoft.com/sqlserver/2004/07/showplan">
+  <BatchSequence><Batch><Statements>
+    <StmtSimple StatementText="select * from t where id = 1" StatementType="SELECT" StatementSubTreeCost="0.0065" StatementEstRows="1">
+      <QueryPlan>
+        <RelOp PhysicalOp="Nested Loops" LogicalOp="Inner Join" EstimateRows="1" EstimatedTotalSubtreeCost="0.0065">
+          <NestedLoops>
+            <RelOp PhysicalOp="Index Seek" LogicalOp="Index Seek" EstimateRows="1" EstimatedTotalSubtreeCost="0.0032">
+              <IndexScan><Object Table="[t]" Index="[pk_t]" /></IndexScan>
+            </RelOp>
+            <RelOp PhysicalOp="Key Lookup" LogicalOp="Clustered Index Seek" EstimateRows="1" EstimatedTotalSubtreeCost="0.0032">
+              <IndexScan><Object Table="[t]" Index="[cx_t]" /></IndexScan>
+            </RelOp>
+          </NestedLoops>
+        </RelOp>
+      </QueryPlan>
+    </StmtSimple>
+  </Statements></Batch></BatchSequence>
+</ShowPlanXML>`
+
+	plan, err := parseShowPlanXML(source)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := []string{
+		"SELECT",
+		"  Nested Loops (Inner Join)",
+		"    Index Seek [t].[pk_t]",
+		"    Key Lookup (Clustered Index Seek) [t].[cx_t]",
+	}
+	records := plan.Records()[1:]
+	if len(records) != len(expect) {
+		t.Fatalf("expect %d records, but %d: %#v", len(expect), len(records), records)
+	}
+	for i, e := range expect {
+		if records[i][0] != e {
+			t.Fatalf("%d: expect %q, but %q", i, e, records[i][0])
+		}
+	}
+}
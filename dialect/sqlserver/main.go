@@ -1,6 +1,11 @@
 package sqlserver
 
 import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
 	_ "github.com/microsoft/go-mssqldb"
 	_ "github.com/microsoft/go-mssqldb/namedpipe"
 	_ "github.com/microsoft/go-mssqldb/sharedmemory"
@@ -20,14 +25,54 @@ func sqlServerTypeNameToConv(typeName string) func(string) (any, error) {
 	return nil
 }
 
+// sqlServerTypeName maps the types whose names differ on SQL Server.
+// TIMESTAMP is not a date and time type on it.
+func sqlServerTypeName(spec dialect.ColumnTypeSpec) (string, bool) {
+	switch spec.Kind {
+	case dialect.KindBool:
+		return "BIT", true
+	case dialect.KindTimestamp:
+		return "DATETIME2", true
+	case dialect.KindBinary:
+		return "VARBINARY(MAX)", true
+	case dialect.KindText:
+		if spec.Length > 0 && spec.Length <= 4000 {
+			return fmt.Sprintf("NVARCHAR(%d)", spec.Length), true
+		}
+		return "NVARCHAR(MAX)", true
+	}
+	return "", false
+}
+
+// sqlServerLiteral writes timestamps through DATETIME2 which accepts
+// 7 digits of fractions, binaries as 0x.. and booleans as bits.
+func sqlServerLiteral(v any) (string, bool) {
+	switch x := v.(type) {
+	case time.Time:
+		return "CAST('" + x.Format("2006-01-02 15:04:05.9999999") + "' AS DATETIME2)", true
+	case []byte:
+		return "0x" + strings.ToUpper(hex.EncodeToString(x)), true
+	case bool:
+		if x {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
+
 var sqlServerSpec = &dialect.Entry{
 	Usage: "sqlbless sqlserver://@<HOSTNAME>?database=<DBNAME>",
 	SQLForColumns: `
 	select c.column_id as "ID",
 		   c.name as "NAME",
 		   case
-			 when c.max_length > 0 then
-			   t.name + '(' + convert(varchar,c.max_length) + ')'
+			 when t.name in ('varchar', 'char', 'varbinary', 'binary') then
+			   t.name + '(' + case c.max_length when -1 then 'max' else convert(varchar,c.max_length) end + ')'
+			 when t.name in ('nvarchar', 'nchar') then
+			   t.name + '(' + case c.max_length when -1 then 'max' else convert(varchar,c.max_length/2) end + ')'
+			 when t.name in ('decimal', 'numeric') then
+			   t.name + '(' + convert(varchar,c.precision) + ',' + convert(varchar,c.scale) + ')'
 			 else
 			   t.name
 		   end as "TYPE",
@@ -42,11 +87,44 @@ var sqlServerSpec = &dialect.Entry{
 	   and o.name = @p1
 	   and c.user_type_id = t.user_type_id
 	 order by c.column_id`,
-	SQLForTables:     `select * from sys.tables`,
-	TypeConverterFor: sqlServerTypeNameToConv,
-	PlaceHolder:      &dialect.PlaceHolderName{Prefix: "@", Format: "v"},
-	TableNameField:   "name",
-	ColumnNameField:  "name",
+	SQLForPrimaryKey: `
+	select c.name
+	  from sys.indexes i
+	  join sys.index_columns ic on ic.object_id = i.object_id and ic.index_id = i.index_id
+	  join sys.columns c on c.object_id = ic.object_id and c.column_id = ic.column_id
+	 where i.is_primary_key = 1
+	   and i.object_id = object_id(@p1)
+	 order by ic.key_ordinal`,
+	SQLForForeignKeys: `
+	select fk.name, pc.name, rt.name, rc.name
+	  from sys.foreign_keys fk
+	  join sys.foreign_key_columns fkc on fkc.constraint_object_id = fk.object_id
+	  join sys.columns pc on pc.object_id = fkc.parent_object_id and pc.column_id = fkc.parent_column_id
+	  join sys.tables rt on rt.object_id = fkc.referenced_object_id
+	  join sys.columns rc on rc.object_id = fkc.referenced_object_id and rc.column_id = fkc.referenced_column_id
+	 where fk.parent_object_id = object_id(@p1)
+	 order by fk.name, fkc.constraint_column_id`,
+	SQLForIndexes: `
+	select i.name, i.is_unique, c.name
+	  from sys.indexes i
+	  join sys.index_columns ic on ic.object_id = i.object_id and ic.index_id = i.index_id
+	  join sys.columns c on c.object_id = ic.object_id and c.column_id = ic.column_id
+	 where i.object_id = object_id(@p1)
+	   and i.is_primary_key = 0
+	   and i.type > 0
+	   and ic.is_included_column = 0
+	 order by i.name, ic.key_ordinal`,
+	AlterTableAddConstraint: true,
+	SQLForTables:            `select * from sys.tables`,
+	TypeConverterFor:        sqlServerTypeNameToConv,
+	PlaceHolder:             &dialect.PlaceHolderName{Prefix: "@", Format: "v"},
+	MultiRowValues:          true,
+	TableNameField:          "name",
+	ColumnNameField:         "name",
+	PlanFor:                 explain,
+	LiteralFor:              sqlServerLiteral,
+	TypeNameFor:             sqlServerTypeName,
+	Syntax:                  dialect.Syntax{Bracket: true},
 }
 
 func init() {
@@ -0,0 +1,34 @@
+package dialect
+
+// Syntax describes the lexical rules of a dialect in addition to
+// the standard SQL: '...' for strings, "..." for identifiers,
+// `--` and `/* */` for comments.
+type Syntax struct {
+	// Backtick is true when `...` encloses identifiers (MySQL, SQLite3).
+	Backtick bool
+
+	// Bracket is true when [...] encloses identifiers (SQL Server, SQLite3).
+	Bracket bool
+
+	// DollarQuote is true when $$...$$ or $tag$...$tag$ encloses strings (PostgreSQL).
+	DollarQuote bool
+
+	// EscapeString is true when E'...' allows backslash escapes (PostgreSQL).
+	EscapeString bool
+
+	// BackslashEscape is true when a backslash escapes the next character
+	// in all quoted strings (MySQL).
+	BackslashEscape bool
+
+	// HashComment is true when # starts a comment until the end of the line (MySQL).
+	HashComment bool
+
+	// NestedComment is true when /* */ can be nested (PostgreSQL).
+	NestedComment bool
+
+	// SlashTerminatesBlocks is true when DECLARE and BEGIN blocks and
+	// CREATE PROCEDURE, FUNCTION, PACKAGE, TRIGGER and TYPE are terminated
+	// only by a line containing only `/`, because they contain `;` before
+	// their BEGIN (Oracle PL/SQL).
+	SlashTerminatesBlocks bool
+}
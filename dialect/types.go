@@ -0,0 +1,169 @@
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TypeKind is the kind of the values of a column. It is used to map
+// the type of a column of a database to the one of another database.
+type TypeKind int
+
+const (
+	KindText TypeKind = iota
+	KindInteger
+	KindDecimal
+	KindFloat
+	KindBool
+	KindDate
+	KindTimestamp
+	KindBinary
+)
+
+// ColumnTypeSpec is the kind and the size of a column.
+// Length, Precision and Scale are zero when they are unknown.
+type ColumnTypeSpec struct {
+	Kind      TypeKind
+	Length    int64
+	Precision int64
+	Scale     int64
+}
+
+var (
+	typeOfTime  = reflect.TypeOf(time.Time{})
+	typeOfBytes = reflect.TypeOf([]byte{})
+)
+
+// typeKinds maps the names of types to their kinds.
+var typeKinds = map[string]TypeKind{
+	"BOOL": KindBool, "BOOLEAN": KindBool, "BIT": KindBool,
+
+	"INT": KindInteger, "INTEGER": KindInteger, "TINYINT": KindInteger,
+	"SMALLINT": KindInteger, "MEDIUMINT": KindInteger, "BIGINT": KindInteger,
+	"INT2": KindInteger, "INT4": KindInteger, "INT8": KindInteger,
+	"SERIAL": KindInteger, "SMALLSERIAL": KindInteger, "BIGSERIAL": KindInteger,
+	"SERIAL2": KindInteger, "SERIAL4": KindInteger, "SERIAL8": KindInteger,
+
+	"NUMERIC": KindDecimal, "DECIMAL": KindDecimal, "DEC": KindDecimal,
+	"NUMBER": KindDecimal, "MONEY": KindDecimal, "SMALLMONEY": KindDecimal,
+
+	"FLOAT": KindFloat, "FLOAT4": KindFloat, "FLOAT8": KindFloat,
+	"DOUBLE": KindFloat, "REAL": KindFloat,
+	"BINARY_FLOAT": KindFloat, "BINARY_DOUBLE": KindFloat,
+
+	"DATE": KindDate,
+
+	"TIMESTAMP": KindTimestamp, "TIMESTAMPTZ": KindTimestamp,
+	"DATETIME": KindTimestamp, "DATETIME2": KindTimestamp,
+	"SMALLDATETIME": KindTimestamp, "DATETIMEOFFSET": KindTimestamp,
+
+	"BLOB": KindBinary, "TINYBLOB": KindBinary, "MEDIUMBLOB": KindBinary,
+	"LONGBLOB": KindBinary, "BYTEA": KindBinary, "BINARY": KindBinary,
+	"VARBINARY": KindBinary, "RAW": KindBinary, "LONG RAW": KindBinary,
+	"IMAGE": KindBinary,
+
+	"CHAR": KindText, "VARCHAR": KindText, "VARCHAR2": KindText,
+	"NCHAR": KindText, "NVARCHAR": KindText, "NVARCHAR2": KindText,
+	"CHARACTER": KindText, "BPCHAR": KindText, "TEXT": KindText,
+	"TINYTEXT": KindText, "MEDIUMTEXT": KindText, "LONGTEXT": KindText,
+	"NTEXT": KindText, "CLOB": KindText, "NCLOB": KindText, "LONG": KindText,
+	"TIME": KindText, "TIMETZ": KindText, "INTERVAL": KindText,
+}
+
+// baseTypeName returns the name of the type without the size, the
+// modifiers such as WITH TIME ZONE and UNSIGNED, in upper case.
+// e.g. `NUMBER(10,2)` to `NUMBER`, `UNSIGNED BIGINT` to `BIGINT`
+func baseTypeName(name string) string {
+	name, _, _ = strings.Cut(strings.ToUpper(name), "(")
+	var words []string
+	for _, word := range strings.Fields(name) {
+		if word != "UNSIGNED" && word != "SIGNED" {
+			words = append(words, word)
+		}
+	}
+	if len(words) <= 0 {
+		return ""
+	}
+	if len(words) >= 2 && words[0] == "LONG" && words[1] == "RAW" {
+		return "LONG RAW"
+	}
+	return words[0]
+}
+
+// KindOf guesses the kind of the column from the name of the type and
+// the type of Go used to scan it.
+func KindOf(ct *sql.ColumnType) TypeKind {
+	if kind, ok := typeKinds[baseTypeName(ct.DatabaseTypeName())]; ok {
+		return kind
+	}
+	switch t := ct.ScanType(); {
+	case t == nil:
+		return KindText
+	case t == typeOfTime:
+		return KindTimestamp
+	case t == typeOfBytes:
+		return KindText
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			return KindBool
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return KindInteger
+		case reflect.Float32, reflect.Float64:
+			return KindFloat
+		}
+	}
+	return KindText
+}
+
+// SpecOf returns the kind and the size of the column.
+func SpecOf(ct *sql.ColumnType) ColumnTypeSpec {
+	spec := ColumnTypeSpec{Kind: KindOf(ct)}
+	if length, ok := ct.Length(); ok && length > 0 {
+		spec.Length = length
+	}
+	if precision, scale, ok := ct.DecimalSize(); ok && precision > 0 {
+		spec.Precision, spec.Scale = precision, scale
+	}
+	return spec
+}
+
+// maxVarcharLength is the longest text written as VARCHAR(n) by default.
+const maxVarcharLength = 4000
+
+// TypeNameOf returns the name of the type of this dialect for the column
+// of another database. TypeNameFor of the dialect is tried first.
+func (D *Entry) TypeNameOf(spec ColumnTypeSpec) string {
+	if f := D.TypeNameFor; f != nil {
+		if name, ok := f(spec); ok {
+			return name
+		}
+	}
+	switch spec.Kind {
+	case KindInteger:
+		return "BIGINT"
+	case KindDecimal:
+		if spec.Precision > 0 {
+			return fmt.Sprintf("NUMERIC(%d,%d)", spec.Precision, spec.Scale)
+		}
+		return "NUMERIC"
+	case KindFloat:
+		return "DOUBLE PRECISION"
+	case KindBool:
+		return "BOOLEAN"
+	case KindDate:
+		return "DATE"
+	case KindTimestamp:
+		return "TIMESTAMP"
+	case KindBinary:
+		return "BLOB"
+	}
+	if spec.Length > 0 && spec.Length <= maxVarcharLength {
+		return fmt.Sprintf("VARCHAR(%d)", spec.Length)
+	}
+	return "TEXT"
+}
@@ -0,0 +1,40 @@
+package dialect
+
+import (
+	"testing"
+)
+
+func TestIsNumericTypeName(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"INTEGER":          true,
+		"UNSIGNED BIGINT":  true,
+		"NUMBER(10,2)":     true,
+		"DOUBLE PRECISION": true,
+		"FLOAT8":           true,
+		"YEAR":             true,
+		"POINT":            false,
+		"INTERVAL":         false,
+		"MONEY":            false,
+		"_INT4":            false,
+		"VARCHAR(20)":      false,
+		"TIMESTAMP":        false,
+	} {
+		if actual := IsNumericTypeName(name); actual != expected {
+			t.Fatalf("%s: expect %v, but %v", name, expected, actual)
+		}
+	}
+}
+
+func TestBaseTypeName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"timestamp(6) with time zone": "TIMESTAMP",
+		"BIGINT UNSIGNED":             "BIGINT",
+		"LONG RAW":                    "LONG RAW",
+		"CHARACTER VARYING":           "CHARACTER",
+		"":                            "",
+	} {
+		if actual := baseTypeName(name); actual != expected {
+			t.Fatalf("%q: expect %q, but %q", name, expected, actual)
+		}
+	}
+}
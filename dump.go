@@ -0,0 +1,303 @@
+package sqlbless
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hymkor/sqlbless/dialect"
+	"github.com/hymkor/sqlbless/rowstocsv"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+)
+
+var (
+	ErrInvalidDumpSyntax = errors.New("invalid syntax: expected 'DUMP [table ...] TO file [CONSTRAINTS]'")
+)
+
+type dumpSpec struct {
+	tables      []string
+	file        string
+	constraints bool
+}
+
+// parseDumpArgs parses `[table ...] TO file [CONSTRAINTS]`.
+func parseDumpArgs(arg string) (*dumpSpec, error) {
+	spec := &dumpSpec{}
+	for {
+		var word string
+		word, arg = misc.CutField(arg)
+		if word == "" {
+			return nil, ErrInvalidDumpSyntax
+		}
+		if strings.EqualFold(word, "TO") {
+			break
+		}
+		spec.tables = append(spec.tables, strings.TrimRight(word, ","))
+	}
+	spec.file, arg = cutWord(arg)
+	if spec.file == "" {
+		return nil, ErrInvalidDumpSyntax
+	}
+	for {
+		word, rest := misc.CutField(arg)
+		if word == "" {
+			break
+		}
+		if !strings.EqualFold(word, "CONSTRAINTS") {
+			return nil, ErrInvalidDumpSyntax
+		}
+		spec.constraints = true
+		arg = rest
+	}
+	return spec, nil
+}
+
+type dumpTable struct {
+	name        string
+	columns     []dialect.Column
+	constraints *dialect.Constraints
+}
+
+// sortByDependency orders the tables so that the tables referenced by
+// foreign keys come first. Tables in a cycle keep their order.
+func sortByDependency(tables []*dumpTable) []*dumpTable {
+	byName := map[string]*dumpTable{}
+	for _, t := range tables {
+		byName[strings.ToUpper(t.name)] = t
+	}
+	visited := map[*dumpTable]bool{}
+	result := make([]*dumpTable, 0, len(tables))
+	var visit func(t *dumpTable)
+	visit = func(t *dumpTable) {
+		if _, ok := visited[t]; ok {
+			return
+		}
+		visited[t] = false
+		if t.constraints != nil {
+			for _, fk := range t.constraints.ForeignKeys {
+				if ref, ok := byName[strings.ToUpper(fk.RefTable)]; ok {
+					visit(ref)
+				}
+			}
+		}
+		visited[t] = true
+		result = append(result, t)
+	}
+	for _, t := range tables {
+		visit(t)
+	}
+	return result
+}
+
+func (ss *session) identifierList(names []string) string {
+	enclosed := make([]string, len(names))
+	for i, name := range names {
+		enclosed[i] = ss.Dialect.EncloseIdentifierIfNeed(name)
+	}
+	return strings.Join(enclosed, ", ")
+}
+
+// foreignKeySQL returns the FOREIGN KEY clause of the constraint.
+func (ss *session) foreignKeySQL(fk *dialect.ForeignKey) string {
+	line := ""
+	if fk.Name != "" {
+		line = "CONSTRAINT " + ss.Dialect.EncloseIdentifierIfNeed(fk.Name) + " "
+	}
+	return line + fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+		ss.identifierList(fk.Columns),
+		ss.Dialect.EncloseIdentifierIfNeed(fk.RefTable),
+		ss.identifierList(fk.RefColumns))
+}
+
+// createTableSQL returns the CREATE TABLE statement with the primary key.
+// The unique keys are included only with CONSTRAINTS, and so are the
+// foreign keys when withForeignKeys is true.
+func (ss *session) createTableSQL(t *dumpTable, withConstraints, withForeignKeys bool) string {
+	var lines []string
+	for _, c := range t.columns {
+		line := ss.Dialect.EncloseIdentifierIfNeed(c.Name)
+		if c.Type != "" {
+			line += " " + c.Type
+		}
+		if c.NotNull {
+			line += " NOT NULL"
+		}
+		lines = append(lines, line)
+	}
+	if t.constraints != nil {
+		if len(t.constraints.PrimaryKey) > 0 {
+			lines = append(lines, "PRIMARY KEY ("+ss.identifierList(t.constraints.PrimaryKey)+")")
+		}
+		if withConstraints {
+			for _, uk := range t.constraints.UniqueKeys {
+				line := ""
+				if uk.Name != "" {
+					line = "CONSTRAINT " + ss.Dialect.EncloseIdentifierIfNeed(uk.Name) + " "
+				}
+				lines = append(lines, line+"UNIQUE ("+ss.identifierList(uk.Columns)+")")
+			}
+		}
+		if withForeignKeys {
+			for i := range t.constraints.ForeignKeys {
+				lines = append(lines, ss.foreignKeySQL(&t.constraints.ForeignKeys[i]))
+			}
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n    %s\n);\n",
+		ss.Dialect.EncloseIdentifierIfNeed(t.name), strings.Join(lines, ",\n    "))
+}
+
+// addForeignKeySQL returns the ALTER TABLE statement to add the foreign key.
+func (ss *session) addForeignKeySQL(t *dumpTable, fk *dialect.ForeignKey) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s;\n",
+		ss.Dialect.EncloseIdentifierIfNeed(t.name), ss.foreignKeySQL(fk))
+}
+
+func (ss *session) createIndexSQL(t *dumpTable, index *dialect.Index) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);\n", unique,
+		ss.Dialect.EncloseIdentifierIfNeed(index.Name),
+		ss.Dialect.EncloseIdentifierIfNeed(t.name),
+		ss.identifierList(index.Columns))
+}
+
+// writeInserts writes the rows of the table as INSERT statements and
+// returns the number of them.
+func (ss *session) writeInserts(ctx context.Context, w io.Writer, conn dialect.CanQuery, t *dumpTable) (int64, error) {
+	table := ss.Dialect.EncloseIdentifierIfNeed(t.name)
+	rows, err := conn.QueryContext(ctx, "SELECT * FROM "+table)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", t.name, err)
+	}
+	defer rows.Close()
+	cfg := ss.encoderConfig(table)
+	cfg.UseCRLF = ss.CrLf
+	enc, err := rowstocsv.NewEncoder("insert", w, cfg)
+	if err != nil {
+		return 0, err
+	}
+	tr := newTimedRows(rows)
+	err = rowstocsv.Config{}.Encode(ctx, tr, enc)
+	return tr.count, err
+}
+
+// dump writes the script to create the tables and to insert their rows.
+// The rows are committed before the indexes are created, because some
+// databases can not execute DDL in the transaction. The foreign keys are
+// added after the indexes when the dialect can alter tables with them,
+// so that the tables referencing each other can be restored.
+func (ss *session) dump(ctx context.Context, w io.Writer, spec *dumpSpec) (int, int64, error) {
+	var conn dialect.CanQuery = ss.conn
+	if ss.tx != nil {
+		conn = ss.tx
+	}
+	names := spec.tables
+	if len(names) <= 0 {
+		all, err := ss.Dialect.FetchTables(ctx, conn)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, name := range all {
+			if !strings.HasPrefix(strings.ToLower(name), "sqlite_") {
+				names = append(names, name)
+			}
+		}
+	}
+	tables := make([]*dumpTable, 0, len(names))
+	for _, name := range names {
+		columns, err := ss.Dialect.FetchColumnDefinitions(ctx, conn, name)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(columns) <= 0 {
+			return 0, 0, fmt.Errorf("%s: table not found", name)
+		}
+		constraints, err := ss.Dialect.FetchConstraints(ctx, conn, name)
+		if err != nil && !errors.Is(err, dialect.ErrNotSupported) {
+			return 0, 0, fmt.Errorf("%s: %w", name, err)
+		}
+		tables = append(tables, &dumpTable{name: name, columns: columns, constraints: constraints})
+	}
+	tables = sortByDependency(tables)
+
+	nl := "\n"
+	if ss.CrLf {
+		nl = "\r\n"
+	}
+	write := func(s string) {
+		io.WriteString(w, strings.ReplaceAll(s, "\n", nl))
+	}
+	write(fmt.Sprintf("-- Dumped by SQL-Bless %s at %s\n", Version, time.Now().Format(time.DateTime)))
+	alterTable := spec.constraints && ss.Dialect.AlterTableAddConstraint
+	for _, t := range tables {
+		write(ss.createTableSQL(t, spec.constraints, spec.constraints && !alterTable))
+	}
+	var count int64
+	for _, t := range tables {
+		n, err := ss.writeInserts(ctx, w, conn, t)
+		if err != nil {
+			return 0, 0, err
+		}
+		count += n
+	}
+	write("COMMIT;\n")
+	if spec.constraints {
+		for _, t := range tables {
+			if t.constraints == nil {
+				continue
+			}
+			for i := range t.constraints.Indexes {
+				write(ss.createIndexSQL(t, &t.constraints.Indexes[i]))
+			}
+		}
+	}
+	if alterTable {
+		for _, t := range tables {
+			if t.constraints == nil {
+				continue
+			}
+			for i := range t.constraints.ForeignKeys {
+				write(ss.addForeignKeySQL(t, &t.constraints.ForeignKeys[i]))
+			}
+		}
+	}
+	return len(tables), count, nil
+}
+
+// doDump writes the DDL and the data of the tables into the file as
+// a script which can be run with START.
+func doDump(ctx context.Context, ss *session, arg string) error {
+	spec, err := parseDumpArgs(arg)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	fd, err := os.Create(spec.file)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(fd)
+	tables, rows, err := ss.dump(ctx, bw, spec)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err1 := fd.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(spec.file)
+		return err
+	}
+	fmt.Fprintf(ss.stdErr, "%d table(s) and %d row(s) dumped to %s\n", tables, rows, spec.file)
+	ss.printElapsed("dump", start)
+	return nil
+}
@@ -0,0 +1,151 @@
+package sqlbless
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func TestParseDumpArgs(t *testing.T) {
+	for _, c := range []struct {
+		arg    string
+		expect *dumpSpec
+		err    error
+	}{
+		{
+			arg:    "TO dump.sql",
+			expect: &dumpSpec{file: "dump.sql"},
+		},
+		{
+			arg:    "A, B to 'my dump.sql' CONSTRAINTS",
+			expect: &dumpSpec{tables: []string{"A", "B"}, file: "my dump.sql", constraints: true},
+		},
+		{arg: "A B", err: ErrInvalidDumpSyntax},
+		{arg: "TO", err: ErrInvalidDumpSyntax},
+		{arg: "TO dump.sql DATA", err: ErrInvalidDumpSyntax},
+	} {
+		spec, err := parseDumpArgs(c.arg)
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Fatalf("%s: expect %v, but %v", c.arg, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", c.arg, err.Error())
+		}
+		if !reflect.DeepEqual(spec, c.expect) {
+			t.Fatalf("%s: expect %#v, but %#v", c.arg, c.expect, spec)
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	dir := t.TempDir()
+	dumpPath := filepath.Join(dir, "dump.sql")
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CREATE TABLE A_CHILD ( ID INTEGER NOT NULL, PARENT_ID INTEGER,
+				PRIMARY KEY (ID),
+				CONSTRAINT FK_PARENT FOREIGN KEY (PARENT_ID) REFERENCES B_PARENT (ID) );
+			CREATE TABLE B_PARENT ( ID INTEGER NOT NULL PRIMARY KEY, NAME CHAR VARYING(20),
+				CODE INTEGER UNIQUE );
+			CREATE INDEX IX_NAME ON B_PARENT (NAME);
+			INSERT INTO B_PARENT VALUES (1, 'it''s', NULL);
+			INSERT INTO A_CHILD VALUES (10, 1);
+			DUMP TO '` + dumpPath + `' CONSTRAINTS;
+			DROP TABLE A_CHILD;
+			DROP TABLE B_PARENT;
+			START '` + dumpPath + `';
+			SPOOL ` + spool + `;
+			SELECT C.ID, P.NAME FROM A_CHILD C JOIN B_PARENT P ON C.PARENT_ID = P.ID;
+			SPOOL OFF;`,
+	})
+	if err := runScriptFile(t, filepath.Join(dir, "main.sql")); err != nil {
+		t.Fatal(err.Error())
+	}
+	dump, err := os.ReadFile(dumpPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	text := string(dump)
+	for _, expect := range []string{
+		"CREATE TABLE B_PARENT (\n    ID INTEGER NOT NULL,\n    NAME CHAR VARYING(20),\n    CODE INTEGER,\n    PRIMARY KEY (ID),\n    CONSTRAINT uq_B_PARENT_1 UNIQUE (CODE)\n);\n",
+		"FOREIGN KEY (PARENT_ID) REFERENCES B_PARENT (ID)\n);\n",
+		"INSERT INTO B_PARENT (ID, NAME, CODE) VALUES (1, 'it''s', NULL);\n",
+		"COMMIT;\nCREATE INDEX IX_NAME ON B_PARENT (NAME);\n",
+	} {
+		if !strings.Contains(text, expect) {
+			t.Fatalf("%q is not found in %q", expect, text)
+		}
+	}
+	if strings.Index(text, "CREATE TABLE B_PARENT") > strings.Index(text, "CREATE TABLE A_CHILD") {
+		t.Fatalf("the referenced table is not created first: %s", text)
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.Contains(string(output), "\n10,it's\n") {
+		t.Fatalf("the dump was not restored: %s", string(output))
+	}
+}
+
+func TestDumpAddsForeignKeysAfterRows(t *testing.T) {
+	d, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	db, err := sql.Open(d.Driver, d.DataSource)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer conn.Close()
+	for _, s := range []string{
+		"CREATE TABLE A ( ID INTEGER PRIMARY KEY, B_ID INTEGER, CONSTRAINT FK_B FOREIGN KEY (B_ID) REFERENCES B (ID) )",
+		"CREATE TABLE B ( ID INTEGER PRIMARY KEY, A_ID INTEGER, CONSTRAINT FK_A FOREIGN KEY (A_ID) REFERENCES A (ID) )",
+		"INSERT INTO A VALUES (1, 2)",
+		"INSERT INTO B VALUES (2, 1)",
+	} {
+		if _, err := conn.ExecContext(ctx, s); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	// The foreign keys referencing each other are added to the tables
+	// after the rows when the dialect can alter tables with them.
+	altering := *d.Dialect
+	altering.AlterTableAddConstraint = true
+	ss := &session{Config: New(), Dialect: &altering, conn: conn}
+	var buffer bytes.Buffer
+	if _, _, err := ss.dump(ctx, &buffer, &dumpSpec{constraints: true}); err != nil {
+		t.Fatal(err.Error())
+	}
+	text := buffer.String()
+	if strings.Contains(text, "FOREIGN KEY (B_ID) REFERENCES B (ID)\n);") {
+		t.Fatalf("the foreign key is in CREATE TABLE: %s", text)
+	}
+	commit := strings.Index(text, "COMMIT;\n")
+	for _, expect := range []string{
+		"ALTER TABLE A ADD CONSTRAINT fk_A_0 FOREIGN KEY (B_ID) REFERENCES B (ID);\n",
+		"ALTER TABLE B ADD CONSTRAINT fk_B_0 FOREIGN KEY (A_ID) REFERENCES A (ID);\n",
+	} {
+		if i := strings.Index(text, expect); i < commit {
+			t.Fatalf("%q is not found after COMMIT in %q", expect, text)
+		}
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/nyaosorg/go-box/v3"
 
@@ -58,8 +58,6 @@ func newViewer(ss *session) *spread.Viewer {
 	}
 }
 
-var rxNonQuote = regexp.MustCompile(`^\w+$`)
-
 func chooseTable(ctx context.Context, tables []string, d *dialect.Entry, ttyout io.Writer) (string, error) {
 	fmt.Fprintln(ttyout, "Select a table:")
 	table, err := box.SelectString(tables, false, ttyout)
@@ -70,11 +68,7 @@ func chooseTable(ctx context.Context, tables []string, d *dialect.Entry, ttyout
 	if len(table) < 1 {
 		return "", nil
 	}
-	targetTable := table[0]
-	if !rxNonQuote.MatchString(targetTable) {
-		targetTable = d.EncloseIdentifier(table[0])
-	}
-	return targetTable, nil
+	return d.EncloseIdentifierIfNeed(table[0]), nil
 }
 
 func doEdit(ctx context.Context, ss *session, command string, pilot commandIn) error {
@@ -85,8 +79,9 @@ func doEdit(ctx context.Context, ss *session, command string, pilot commandIn) e
 			Null:        ss.Null,
 		},
 		Entry: ss.Dialect,
-		Exec:  (&askSqlAndExecute{getKey: pilot.GetKey, session: ss}).Exec,
 	}
+	ask := &askSqlAndExecute{getKey: pilot.GetKey, session: ss}
+	editor.Exec = ask.Exec
 	if a, ok := pilot.AutoPilotForCsvi(); ok {
 		editor.Pilot = misc.AutoCsvi{GetKeyAndSize: a}
 	}
@@ -107,7 +102,11 @@ func doEdit(ctx context.Context, ss *session, command string, pilot commandIn) e
 			return err
 		}
 	}
-	return editor.Edit(ctx, tableAndWhere, ss.termOut)
+	err := editor.Edit(ctx, tableAndWhere, ss.termOut)
+	if ask.applied > 0 {
+		ss.printDuration("apply", ask.elapsed)
+	}
+	return err
 }
 
 func joinAny(args []any) string {
@@ -135,8 +134,10 @@ const (
 )
 
 type askSqlAndExecute struct {
-	status statusValue
-	getKey func() (string, error)
+	status  statusValue
+	getKey  func() (string, error)
+	applied int
+	elapsed time.Duration
 	*session
 }
 
@@ -191,7 +192,7 @@ func (ss *askSqlAndExecute) Exec(ctx context.Context, dmlSql string, args ...any
 		}
 	}
 	isNewTx := (ss.tx == nil)
-	err := ss.beginTx(ctx, ss.stdErr)
+	err := ss.beginTx(ss.stdErr)
 	if err != nil {
 		return nil, err
 	}
@@ -199,7 +200,10 @@ func (ss *askSqlAndExecute) Exec(ctx context.Context, dmlSql string, args ...any
 	if argsString != "" {
 		misc.Echo(ss.spool, argsString)
 	}
+	start := time.Now()
 	result, err := ss.tx.ExecContext(ctx, dmlSql, args...)
+	ss.elapsed += time.Since(start)
+	ss.applied++
 	var count int64
 	if err == nil {
 		count, err = result.RowsAffected()
@@ -0,0 +1,53 @@
+package sqlbless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hymkor/sqlbless/dialect"
+	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/rowstocsv"
+)
+
+// isExplainOfStatement reports whether the operand of EXPLAIN is a
+// statement whose plan the client retrieves. The native EXPLAIN with
+// options such as ANALYZE and FORMAT=JSON or a table name of MySQL is sent
+// to the server as it is.
+func isExplainOfStatement(arg string) bool {
+	word, _ := misc.CutField(arg)
+	switch strings.ToUpper(word) {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE", "WITH", "VALUES":
+		return true
+	}
+	return false
+}
+
+func doExplain(ctx context.Context, ss *session, statement string, commandIn commandIn) error {
+	statement = strings.TrimSpace(statement)
+	if statement == "" {
+		return fmt.Errorf("explain: %w", ErrNoStatement)
+	}
+	var conn dialect.CanQueryAndExec = ss.conn
+	if ss.tx != nil {
+		conn = ss.tx
+	}
+	plan, err := ss.Dialect.Explain(ctx, conn, statement)
+	if errors.Is(err, dialect.ErrSessionNotRestored) && ss.current != nil && ss.current.db != nil {
+		// Replace the connection left in the mode to show plans.
+		if rerr := ss.reconnect(context.Background()); rerr != nil {
+			err = errors.Join(err, rerr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("explain: %w", err)
+	}
+	records := plan.Records()
+	if len(records) <= 1 {
+		return ErrNoDataFound
+	}
+	v := newViewer(ss)
+	v.HeaderLines = 1
+	return viewRows(ctx, ss, "EXPLAIN "+statement, rowstocsv.NewRecords(records), v, commandIn)
+}
@@ -0,0 +1,52 @@
+package sqlbless
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	records := runSQLiteScript(t, `
+		CREATE TABLE TESTTBL ( ID NUMERIC, NAME CHAR VARYING(20), PRIMARY KEY (ID) );
+		INSERT INTO TESTTBL VALUES (10,'FOO');
+
+		SPOOL {{SPOOL}};
+		EXPLAIN DELETE FROM TESTTBL WHERE ID = 10;
+		SELECT 'CNT=',COUNT(*) FROM TESTTBL;
+		SPOOL OFF;
+		ROLLBACK;
+	`)
+	foundPlan := false
+	foundCount := false
+	for _, r := range records {
+		if len(r) >= 1 && strings.Contains(r[0], "TESTTBL") {
+			foundPlan = true
+		}
+		if len(r) >= 2 && r[0] == "CNT=" && r[1] == "1" {
+			foundCount = true
+		}
+	}
+	if !foundPlan {
+		t.Fatalf("plan not found: %#v", records)
+	}
+	if !foundCount {
+		t.Fatalf("EXPLAIN executed DELETE: %#v", records)
+	}
+}
+
+func TestNativeExplain(t *testing.T) {
+	records := runSQLiteScript(t, `
+		CREATE TABLE TESTTBL ( ID NUMERIC, NAME CHAR VARYING(20), PRIMARY KEY (ID) );
+		SPOOL {{SPOOL}};
+		EXPLAIN QUERY PLAN SELECT * FROM TESTTBL WHERE ID = 10;
+		SPOOL OFF;
+	`)
+	for _, r := range records {
+		for _, field := range r {
+			if strings.Contains(field, "SEARCH TESTTBL") {
+				return
+			}
+		}
+	}
+	t.Fatalf("plan of the server not found: %#v", records)
+}
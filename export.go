@@ -0,0 +1,136 @@
+package sqlbless
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hymkor/sqlbless/rowstocsv"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/internal/sqllex"
+)
+
+var (
+	ErrInvalidExportSyntax = errors.New("invalid syntax: expected 'EXPORT TO file [FORMAT name [INTO table]] select-statement'")
+)
+
+type exportSpec struct {
+	file   string
+	format string
+	table  string
+	query  string
+}
+
+// isEncoderFormat reports whether the name is a format of rowstocsv.NewEncoder.
+func isEncoderFormat(name string) bool {
+	if name == "md" {
+		return true
+	}
+	for _, f := range rowstocsv.Formats {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExportArgs parses `TO file [FORMAT name [INTO table]] statement`.
+func parseExportArgs(arg string, comma byte) (*exportSpec, error) {
+	spec := &exportSpec{}
+	to, arg := misc.CutField(arg)
+	if !strings.EqualFold(to, "TO") {
+		return nil, ErrInvalidExportSyntax
+	}
+	spec.file, arg = cutWord(arg)
+	if spec.file == "" {
+		return nil, ErrInvalidExportSyntax
+	}
+	if word, rest := misc.CutField(arg); strings.EqualFold(word, "FORMAT") {
+		word, arg = misc.CutField(rest)
+		spec.format = strings.ToLower(word)
+		if !isEncoderFormat(spec.format) {
+			return nil, fmt.Errorf("%w: %s (expected %s)", rowstocsv.ErrUnknownFormat,
+				word, strings.Join(rowstocsv.Formats, ", "))
+		}
+		if word, rest := misc.CutField(arg); strings.EqualFold(word, "INTO") {
+			spec.table, arg = misc.CutField(rest)
+			if spec.table == "" {
+				return nil, ErrInvalidExportSyntax
+			}
+		}
+	}
+	if strings.TrimSpace(arg) == "" {
+		return nil, ErrInvalidExportSyntax
+	}
+	spec.query = arg
+	if spec.format == "" {
+		if spec.format = fileFormatOf(spec.file); spec.format == "" {
+			spec.format = "csv"
+			if comma == '\t' {
+				spec.format = "tsv"
+			}
+		}
+	}
+	return spec, nil
+}
+
+// doExport writes the result of the query into the file without the viewer.
+// The file is removed when the export fails.
+func doExport(ctx context.Context, ss *session, arg string, commandIn commandIn) error {
+	spec, err := parseExportArgs(arg, ss.comma())
+	if err != nil {
+		return err
+	}
+	if spec.format == "insert" && spec.table == "" {
+		spec.table = sqllex.FirstTable(spec.query, ss.Dialect.Syntax)
+	}
+	query, args, err := ss.expandBindVariables(ctx, spec.query, commandIn)
+	if err != nil {
+		return err
+	}
+	echoArgs(ss.spool, args)
+
+	start := time.Now()
+	var rows *sql.Rows
+	if ss.tx != nil {
+		rows, err = ss.tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = ss.conn.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("query: %[1]w (%[1]T)", err)
+	}
+	defer rows.Close()
+
+	fd, err := os.Create(spec.file)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(fd)
+	cfg := ss.encoderConfig(spec.table)
+	cfg.UseCRLF = ss.CrLf
+	enc, err := rowstocsv.NewEncoder(spec.format, w, cfg)
+	tr := newTimedRows(rows)
+	if err == nil {
+		err = rowstocsv.Config{}.Encode(ctx, tr, enc)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err1 := fd.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(spec.file)
+		return err
+	}
+	fmt.Fprintf(ss.stdErr, "%d row(s) exported to %s\n", tr.count, spec.file)
+	fmt.Fprintf(ss.stdErr, "Elapsed (export): %s\n", formatElapsed(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,94 @@
+package sqlbless
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hymkor/sqlbless/rowstocsv"
+)
+
+func TestParseExportArgs(t *testing.T) {
+	for _, c := range []struct {
+		arg    string
+		expect *exportSpec
+		err    error
+	}{
+		{
+			arg:    "TO a.csv SELECT * FROM T",
+			expect: &exportSpec{file: "a.csv", format: "csv", query: " SELECT * FROM T"},
+		},
+		{
+			arg:    "to 'my file.txt' FORMAT TSV SELECT 1",
+			expect: &exportSpec{file: "my file.txt", format: "tsv", query: " SELECT 1"},
+		},
+		{
+			arg:    "TO a.json WITH X AS (SELECT 1) SELECT * FROM X",
+			expect: &exportSpec{file: "a.json", format: "json", query: " WITH X AS (SELECT 1) SELECT * FROM X"},
+		},
+		{
+			arg:    "TO a.txt FORMAT insert INTO T2 SELECT * FROM T",
+			expect: &exportSpec{file: "a.txt", format: "insert", table: "T2", query: " SELECT * FROM T"},
+		},
+		{
+			arg:    "TO book.xlsx SELECT 1",
+			expect: &exportSpec{file: "book.xlsx", format: "xlsx", query: " SELECT 1"},
+		},
+		{arg: "a.csv SELECT 1", err: ErrInvalidExportSyntax},
+		{arg: "TO a.csv", err: ErrInvalidExportSyntax},
+		{arg: "TO a.csv FORMAT xml SELECT 1", err: rowstocsv.ErrUnknownFormat},
+	} {
+		spec, err := parseExportArgs(c.arg, ',')
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Fatalf("%s: expect %v, but %v", c.arg, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", c.arg, err.Error())
+		}
+		if !reflect.DeepEqual(spec, c.expect) {
+			t.Fatalf("%s: expect %#v, but %#v", c.arg, c.expect, spec)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "out.csv")
+	tsvPath := filepath.Join(dir, "out.tsv")
+	jsonPath := filepath.Join(dir, "out.json")
+	sqlPath := filepath.Join(dir, "out.sql")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CREATE TABLE T ( ID INTEGER, NAME CHAR VARYING(20) );
+			INSERT INTO T VALUES (1, 'a,b');
+			INSERT INTO T VALUES (2, NULL);
+			EXPORT TO '` + csvPath + `' SELECT * FROM T ORDER BY ID;
+			EXPORT TO '` + tsvPath + `' SELECT * FROM T WHERE ID > 1;
+			EXPORT TO '` + jsonPath + `' SELECT * FROM T ORDER BY ID;
+			EXPORT TO '` + sqlPath + `' SELECT * FROM T ORDER BY ID;
+			ROLLBACK;`,
+	})
+	if err := runScriptFile(t, filepath.Join(dir, "main.sql")); err != nil {
+		t.Fatal(err.Error())
+	}
+	for fname, expect := range map[string]string{
+		csvPath:  "ID,NAME\n1,\"a,b\"\n2,␀\n",
+		tsvPath:  "ID\tNAME\n2\t␀\n",
+		jsonPath: "[\n{\"ID\":1,\"NAME\":\"a,b\"},\n{\"ID\":2,\"NAME\":null}\n]\n",
+		sqlPath: "INSERT INTO T (ID, NAME) VALUES (1, 'a,b');\n" +
+			"INSERT INTO T (ID, NAME) VALUES (2, NULL);\n",
+	} {
+		output, err := os.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if string(output) != expect {
+			t.Fatalf("%s: expect %q, but %q", fname, expect, string(output))
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package sqlbless
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hymkor/sqlbless/rowstocsv"
+)
+
+var ErrBinaryFormat = errors.New("the binary format can not be written into the spool")
+
+// encoderConfig returns the settings of the encoders for the results.
+// table is the name used by INSERT statements.
+func (ss *session) encoderConfig(table string) rowstocsv.Config {
+	return rowstocsv.Config{
+		Comma:   rune(ss.comma()),
+		Null:    ss.Null,
+		Table:   table,
+		Dialect: ss.Dialect,
+	}
+}
+
+// setFormat sets the format of the results written to the spool.
+// csv is the default and written by the viewer itself.
+func (ss *session) setFormat(value string) error {
+	name := strings.ToLower(strings.TrimSpace(value))
+	if !isEncoderFormat(name) {
+		return fmt.Errorf("%w: %s (expected %s)", rowstocsv.ErrUnknownFormat,
+			value, strings.Join(rowstocsv.Formats, ", "))
+	}
+	if name == "xlsx" {
+		return fmt.Errorf("%w: %s", ErrBinaryFormat, value)
+	}
+	if name == "csv" {
+		name = ""
+	}
+	ss.format = name
+	return nil
+}
+
+// spoolRows returns rows which also write themselves into the spool in
+// the format set by SET FORMAT. When the format is the default, rows are
+// returned as they are and the viewer writes CSV into the spool.
+func (ss *session) spoolRows(title string, rows rowstocsv.Source) (rowstocsv.Source, bool, error) {
+	if ss.format == "" || ss.spool == nil {
+		return rows, false, nil
+	}
+	enc, err := rowstocsv.NewEncoder(ss.format, ss.spool, ss.encoderConfig(ss.tableOf(title)))
+	if err != nil {
+		return nil, false, err
+	}
+	return rowstocsv.Tee(rows, enc), true, nil
+}
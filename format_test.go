@@ -0,0 +1,41 @@
+package sqlbless
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetFormat(t *testing.T) {
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CREATE TABLE T ( ID INTEGER, NAME CHAR VARYING(20) );
+			INSERT INTO T VALUES (1, 'a');
+			INSERT INTO T VALUES (2, NULL);
+			SET FORMAT jsonl;
+			SPOOL ` + spool + `;
+			SELECT * FROM T ORDER BY ID;
+			SET FORMAT insert;
+			SELECT * FROM T WHERE ID = 1;
+			SPOOL OFF;
+			ROLLBACK;`,
+	})
+	if err := runScriptFile(t, filepath.Join(dir, "main.sql")); err != nil {
+		t.Fatal(err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, expect := range []string{
+		"\n" + `{"ID":1,"NAME":"a"}` + "\n" + `{"ID":2,"NAME":null}` + "\n",
+		"\nINSERT INTO T (ID, NAME) VALUES (1, 'a');\n",
+	} {
+		if !strings.Contains(string(output), expect) {
+			t.Fatalf("%q is not found in %q", expect, string(output))
+		}
+	}
+}
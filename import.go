@@ -0,0 +1,648 @@
+package sqlbless
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hymkor/sqlbless/spread"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/internal/xlsx"
+)
+
+var (
+	ErrInvalidImportSyntax = errors.New("invalid syntax: expected 'IMPORT table FROM file [FORMAT csv|tsv|jsonl|xlsx] [SHEET name] [HEADER] [COLUMNS (col,...)]'")
+	ErrUnknownImportFormat = errors.New("unknown format: expected csv, tsv, jsonl or xlsx")
+	ErrFieldCountMismatch  = errors.New("the number of fields does not match the number of columns")
+	ErrNotJSONObject       = errors.New("not a JSON object")
+)
+
+// importBatchSize is the number of rows inserted between savepoints.
+// They are inserted by the statements of rowsPerInsert rows.
+const importBatchSize = 100
+
+const importSavepoint = "sqlbless_import"
+
+const maxJSONLineSize = 16 * 1024 * 1024
+
+type importSpec struct {
+	table   string
+	file    string
+	format  string
+	sheet   string
+	header  bool
+	columns []string
+}
+
+func parseImportColumns(arg string) ([]string, string, bool) {
+	arg = strings.TrimLeft(arg, " \t\r\n")
+	if !strings.HasPrefix(arg, "(") {
+		return nil, "", false
+	}
+	list, rest, ok := strings.Cut(arg[1:], ")")
+	if !ok {
+		return nil, "", false
+	}
+	var columns []string
+	for _, c := range strings.Split(list, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			return nil, "", false
+		}
+		columns = append(columns, c)
+	}
+	return columns, rest, true
+}
+
+// fileFormatOf guesses the format of the file from its extension.
+// It returns an empty string for unknown extensions.
+func fileFormatOf(fname string) string {
+	switch strings.ToLower(filepath.Ext(fname)) {
+	case ".csv":
+		return "csv"
+	case ".tsv":
+		return "tsv"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	case ".json":
+		return "json"
+	case ".md", ".markdown":
+		return "markdown"
+	case ".html", ".htm":
+		return "html"
+	case ".sql":
+		return "insert"
+	case ".xlsx":
+		return "xlsx"
+	}
+	return ""
+}
+
+// parseImportArgs parses `table FROM file [FORMAT csv|tsv|jsonl|xlsx] [SHEET name] [HEADER] [COLUMNS (a,b,c)]`.
+func parseImportArgs(arg string, comma byte) (*importSpec, error) {
+	spec := &importSpec{}
+	var from string
+	spec.table, arg = misc.CutField(arg)
+	from, arg = misc.CutField(arg)
+	if spec.table == "" || !strings.EqualFold(from, "FROM") {
+		return nil, ErrInvalidImportSyntax
+	}
+	spec.file, arg = cutWord(arg)
+	if spec.file == "" {
+		return nil, ErrInvalidImportSyntax
+	}
+	for {
+		arg = strings.TrimLeft(arg, " \t\r\n")
+		if arg == "" {
+			break
+		}
+		if len(arg) >= 7 && strings.EqualFold(arg[:7], "COLUMNS") {
+			var ok bool
+			spec.columns, arg, ok = parseImportColumns(arg[7:])
+			if !ok {
+				return nil, ErrInvalidImportSyntax
+			}
+			continue
+		}
+		var word string
+		word, arg = misc.CutField(arg)
+		switch strings.ToUpper(word) {
+		case "HEADER":
+			spec.header = true
+		case "FORMAT":
+			word, arg = misc.CutField(arg)
+			switch format := strings.ToLower(word); format {
+			case "csv", "tsv", "jsonl", "xlsx":
+				spec.format = format
+			default:
+				return nil, fmt.Errorf("%w: %s", ErrUnknownImportFormat, word)
+			}
+		case "SHEET":
+			spec.sheet, arg = cutWord(arg)
+			if spec.sheet == "" {
+				return nil, ErrInvalidImportSyntax
+			}
+		default:
+			return nil, ErrInvalidImportSyntax
+		}
+	}
+	if spec.format == "" {
+		switch format := fileFormatOf(spec.file); format {
+		case "csv", "tsv", "jsonl", "xlsx":
+			spec.format = format
+		default:
+			spec.format = "csv"
+			if comma == '\t' {
+				spec.format = "tsv"
+			}
+		}
+	}
+	return spec, nil
+}
+
+// importRecord is a row read from the file. original is written to the
+// reject file when the row is rejected.
+type importRecord struct {
+	line     int
+	fields   []string
+	original []string
+}
+
+type importReader interface {
+	Read() (*importRecord, error)
+}
+
+type csvImportReader struct {
+	r *csv.Reader
+}
+
+func newCsvImportReader(r io.Reader, comma rune) *csvImportReader {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xEF\xBB\xBF" {
+		br.Discard(3)
+	}
+	cr := csv.NewReader(br)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	return &csvImportReader{r: cr}
+}
+
+func (c *csvImportReader) Read() (*importRecord, error) {
+	fields, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	line, _ := c.r.FieldPos(0)
+	return &importRecord{line: line, fields: fields, original: fields}, nil
+}
+
+type jsonlImportReader struct {
+	sc      *bufio.Scanner
+	line    int
+	columns []string
+	null    string
+}
+
+func (j *jsonlImportReader) Read() (*importRecord, error) {
+	for j.sc.Scan() {
+		j.line++
+		text := j.sc.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		rec := &importRecord{line: j.line, original: []string{text}}
+		fields, err := j.fields(text)
+		if err != nil {
+			return rec, err
+		}
+		rec.fields = fields
+		return rec, nil
+	}
+	if err := j.sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+type xlsxImportReader struct {
+	rows []xlsx.Row
+}
+
+func (x *xlsxImportReader) Read() (*importRecord, error) {
+	if len(x.rows) <= 0 {
+		return nil, io.EOF
+	}
+	row := x.rows[0]
+	x.rows = x.rows[1:]
+	return &importRecord{line: row.Number, fields: row.Cells, original: row.Cells}, nil
+}
+
+// fields returns the values of the object in the order of columns.
+// Keys are compared case-insensitively when they do not match exactly.
+// Missing keys and JSON null are NULL.
+func (j *jsonlImportReader) fields(text string) ([]string, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, ErrNotJSONObject
+	}
+	fields := make([]string, len(j.columns))
+	for i, name := range j.columns {
+		value, ok := obj[name]
+		if !ok {
+			for key, v := range obj {
+				if strings.EqualFold(key, name) {
+					value = v
+					break
+				}
+			}
+		}
+		switch v := value.(type) {
+		case nil:
+			fields[i] = j.null
+		case string:
+			fields[i] = v
+		case json.Number:
+			fields[i] = v.String()
+		case bool:
+			fields[i] = strconv.FormatBool(v)
+		default:
+			bin, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = string(bin)
+		}
+	}
+	return fields, nil
+}
+
+// rejectWriter writes rejected rows with the error text as CSV.
+// The file is created when the first row is rejected. When the file
+// already exists, a number is appended to the name not to overwrite it.
+type rejectWriter struct {
+	name string
+	fd   *os.File
+	w    *csv.Writer
+}
+
+func (r *rejectWriter) create() (*os.File, error) {
+	name := r.name
+	for i := 1; ; i++ {
+		fd, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			r.name = name
+			return fd, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		name = fmt.Sprintf("%s.%d", r.name, i)
+	}
+}
+
+func (r *rejectWriter) Write(rec *importRecord, err error) error {
+	if r.w == nil {
+		fd, err := r.create()
+		if err != nil {
+			return err
+		}
+		r.fd = fd
+		r.w = csv.NewWriter(fd)
+	}
+	record := append([]string{strconv.Itoa(rec.line), err.Error()}, rec.original...)
+	return r.w.Write(record)
+}
+
+func (r *rejectWriter) Close() error {
+	if r.w == nil {
+		return nil
+	}
+	r.w.Flush()
+	err := r.w.Error()
+	if err1 := r.fd.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+type importer struct {
+	ss         *session
+	stmt       *sql.Stmt
+	table      string
+	columnList string
+	width      int
+	rows       int
+	savepoint  bool
+	batch      []*importRecord
+	values     [][]any
+	imported   int
+	rejected   int
+	rejectFile *rejectWriter
+}
+
+func (imp *importer) reject(rec *importRecord, err error) error {
+	imp.rejected++
+	return imp.rejectFile.Write(rec, err)
+}
+
+func (imp *importer) exec(ctx context.Context, sql string) error {
+	_, err := imp.ss.tx.ExecContext(ctx, sql)
+	return err
+}
+
+// insert inserts the rows by a statement. The prepared one is used for
+// the rows of the full size.
+func (imp *importer) insert(ctx context.Context, rows [][]any) error {
+	ss := imp.ss
+	var query string
+	if len(rows) != imp.rows {
+		query = ss.insertValuesSQL(imp.table, imp.columnList, imp.width, len(rows))
+	}
+	holder := ss.Dialect.PlaceHolder
+	for _, row := range rows {
+		for _, v := range row {
+			holder.Make(v)
+		}
+	}
+	args := holder.Values()
+	var err error
+	if query == "" {
+		_, err = imp.stmt.ExecContext(ctx, args...)
+	} else {
+		_, err = ss.tx.ExecContext(ctx, query, args...)
+	}
+	return err
+}
+
+// insertOneByOne inserts the rows one by one to find the rejected rows.
+// With savepoint, each row is inserted under the savepoint.
+func (imp *importer) insertOneByOne(ctx context.Context, batch []*importRecord, rows [][]any) error {
+	for i, rec := range batch {
+		if imp.savepoint {
+			if err := imp.exec(ctx, "SAVEPOINT "+importSavepoint); err != nil {
+				return err
+			}
+		}
+		if err := imp.insert(ctx, rows[i:i+1]); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			if imp.savepoint {
+				if err := imp.exec(ctx, "ROLLBACK TO SAVEPOINT "+importSavepoint); err != nil {
+					return err
+				}
+			}
+			if err := imp.reject(rec, err); err != nil {
+				return err
+			}
+			continue
+		}
+		imp.imported++
+	}
+	if imp.savepoint {
+		return imp.exec(ctx, "RELEASE SAVEPOINT "+importSavepoint)
+	}
+	return nil
+}
+
+// flush inserts the rows of the batch by the statements of imp.rows rows.
+// When a statement fails, its rows are inserted again one by one to find
+// the rejected rows. When the dialect can not continue the transaction
+// after an error, the batch is inserted under a savepoint, and on an error
+// it is rolled back to the savepoint and all of its rows are inserted
+// again one by one.
+func (imp *importer) flush(ctx context.Context) error {
+	batch, rows := imp.batch, imp.values
+	imp.batch, imp.values = nil, nil
+	if len(batch) <= 0 {
+		return nil
+	}
+	if imp.savepoint {
+		if err := imp.exec(ctx, "SAVEPOINT "+importSavepoint); err != nil {
+			return err
+		}
+		failed := false
+		for i := 0; i < len(rows) && !failed; i += imp.rows {
+			end := i + imp.rows
+			if end > len(rows) {
+				end = len(rows)
+			}
+			if err := imp.insert(ctx, rows[i:end]); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				failed = true
+			}
+		}
+		if !failed {
+			imp.imported += len(batch)
+			return imp.exec(ctx, "RELEASE SAVEPOINT "+importSavepoint)
+		}
+		if err := imp.exec(ctx, "ROLLBACK TO SAVEPOINT "+importSavepoint); err != nil {
+			return err
+		}
+		return imp.insertOneByOne(ctx, batch, rows)
+	}
+	for i := 0; i < len(rows); i += imp.rows {
+		end := i + imp.rows
+		if end > len(rows) {
+			end = len(rows)
+		}
+		if err := imp.insert(ctx, rows[i:end]); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			if err := imp.insertOneByOne(ctx, batch[i:end], rows[i:end]); err != nil {
+				return err
+			}
+			continue
+		}
+		imp.imported += end - i
+	}
+	return nil
+}
+
+func (ss *session) queryColumnTypes(ctx context.Context, query string) ([]string, []*sql.ColumnType, error) {
+	var rows *sql.Rows
+	var err error
+	if ss.tx != nil {
+		rows, err = ss.tx.QueryContext(ctx, query)
+	} else {
+		rows, err = ss.conn.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return columns, columnTypes, nil
+}
+
+// doImport inserts the rows of the file into the table in the transaction.
+// Rows that can not be converted or inserted are written to the reject file
+// `file.reject` and do not stop the import.
+func doImport(ctx context.Context, ss *session, arg string) error {
+	spec, err := parseImportArgs(arg, ss.comma())
+	if err != nil {
+		return err
+	}
+	fd, err := os.Open(spec.file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	var reader importReader
+	columns := spec.columns
+	switch spec.format {
+	case "jsonl":
+		sc := bufio.NewScanner(fd)
+		sc.Buffer(nil, maxJSONLineSize)
+		reader = &jsonlImportReader{sc: sc, null: ss.Null}
+	case "tsv":
+		reader = newCsvImportReader(fd, '\t')
+	case "xlsx":
+		stat, err := fd.Stat()
+		if err != nil {
+			return err
+		}
+		rows, err := xlsx.ReadSheet(fd, stat.Size(), spec.sheet, ss.Null)
+		if err != nil {
+			return fmt.Errorf("%s: %w", spec.file, err)
+		}
+		reader = &xlsxImportReader{rows: rows}
+	default:
+		reader = newCsvImportReader(fd, ',')
+	}
+	if spec.header && spec.format != "jsonl" {
+		rec, err := reader.Read()
+		if err != nil {
+			return fmt.Errorf("%s: header: %w", spec.file, err)
+		}
+		if columns == nil {
+			for _, name := range rec.fields {
+				columns = append(columns, ss.Dialect.EncloseIdentifierIfNeed(strings.TrimSpace(name)))
+			}
+		}
+	}
+	selectList := "*"
+	if columns != nil {
+		selectList = strings.Join(columns, ",")
+	}
+	names, columnTypes, err := ss.queryColumnTypes(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE 1=0", selectList, spec.table))
+	if err != nil {
+		return err
+	}
+	if columns == nil {
+		for _, name := range names {
+			columns = append(columns, ss.Dialect.EncloseIdentifierIfNeed(name))
+		}
+	}
+	if j, ok := reader.(*jsonlImportReader); ok {
+		j.columns = names
+	}
+	converters := spread.NewConverters(columnTypes, ss.Dialect, ss.Null)
+
+	columnList := strings.Join(columns, ",")
+	rows := ss.rowsPerInsert(len(columns))
+	insert := ss.insertValuesSQL(spec.table, columnList, len(columns), rows)
+
+	isNewTx := (ss.tx == nil)
+	if err := ss.beginTx(ss.stdErr); err != nil {
+		return err
+	}
+	start := time.Now()
+	stmt, err := ss.tx.PrepareContext(ctx, insert)
+	if err != nil {
+		if isNewTx && ss.tx != nil {
+			ss.tx.Rollback()
+			ss.tx = nil
+		}
+		return err
+	}
+	imp := &importer{
+		ss:         ss,
+		stmt:       stmt,
+		table:      spec.table,
+		columnList: columnList,
+		width:      len(columns),
+		rows:       rows,
+		savepoint:  ss.Dialect.AbortsTransactionOnError,
+		rejectFile: &rejectWriter{name: spec.file + ".reject"},
+	}
+	err = imp.run(ctx, reader, names, converters)
+	stmt.Close()
+	if err1 := imp.rejectFile.Close(); err == nil {
+		err = err1
+	}
+	fmt.Fprintf(ss.stdErr, "%d row(s) imported, %d row(s) rejected.\n", imp.imported, imp.rejected)
+	if imp.rejected > 0 {
+		fmt.Fprintf(ss.stdErr, "Rejected rows were written to %s\n", imp.rejectFile.name)
+	}
+	ss.printElapsed("import", start)
+	if (err != nil || imp.imported == 0) && isNewTx && ss.tx != nil {
+		ss.tx.Rollback()
+		ss.tx = nil
+	}
+	return err
+}
+
+func (imp *importer) run(ctx context.Context, reader importReader, names []string, converters []spread.Converter) error {
+	null := imp.ss.Null
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if rec == nil && !errors.As(err, &parseErr) {
+				return err
+			}
+			if rec == nil {
+				rec = &importRecord{line: parseErr.Line}
+			}
+			if err := imp.reject(rec, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(rec.fields) != len(converters) {
+			err := fmt.Errorf("%w: expected %d, but got %d",
+				ErrFieldCountMismatch, len(converters), len(rec.fields))
+			if err := imp.reject(rec, err); err != nil {
+				return err
+			}
+			continue
+		}
+		var convErr error
+		values := make([]any, len(rec.fields))
+		for i, field := range rec.fields {
+			v, err := converters[i].Convert(field, null)
+			if err != nil {
+				convErr = fmt.Errorf("%s: %w", names[i], err)
+				break
+			}
+			values[i] = v
+		}
+		if convErr != nil {
+			if err := imp.reject(rec, convErr); err != nil {
+				return err
+			}
+			continue
+		}
+		imp.batch = append(imp.batch, rec)
+		imp.values = append(imp.values, values)
+		if len(imp.batch) >= importBatchSize {
+			if err := imp.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return imp.flush(ctx)
+}
@@ -0,0 +1,213 @@
+package sqlbless
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func TestParseImportArgs(t *testing.T) {
+	for _, c := range []struct {
+		arg    string
+		expect *importSpec
+		err    error
+	}{
+		{
+			arg:    "T FROM data.csv",
+			expect: &importSpec{table: "T", file: "data.csv", format: "csv"},
+		},
+		{
+			arg:    "T FROM 'my data.txt' FORMAT tsv HEADER",
+			expect: &importSpec{table: "T", file: "my data.txt", format: "tsv", header: true},
+		},
+		{
+			arg:    "T FROM data.jsonl COLUMNS( A , B,C )",
+			expect: &importSpec{table: "T", file: "data.jsonl", format: "jsonl", columns: []string{"A", "B", "C"}},
+		},
+		{
+			arg:    "T FROM book.xlsx SHEET 'My Sheet' HEADER",
+			expect: &importSpec{table: "T", file: "book.xlsx", format: "xlsx", sheet: "My Sheet", header: true},
+		},
+		{arg: "T data.csv", err: ErrInvalidImportSyntax},
+		{arg: "T FROM book.xlsx SHEET", err: ErrInvalidImportSyntax},
+		{arg: "T FROM data.csv COLUMNS (A,", err: ErrInvalidImportSyntax},
+		{arg: "T FROM data.csv FORMAT xml", err: ErrUnknownImportFormat},
+	} {
+		spec, err := parseImportArgs(c.arg, ',')
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Fatalf("%s: expect %v, but %v", c.arg, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", c.arg, err.Error())
+		}
+		if !reflect.DeepEqual(spec, c.expect) {
+			t.Fatalf("%s: expect %#v, but %#v", c.arg, c.expect, spec)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CREATE TABLE T ( ID INTEGER NOT NULL, NAME CHAR VARYING(20) );
+			IMPORT T FROM '` + filepath.Join(dir, "data.csv") + `' HEADER;
+			IMPORT T FROM '` + filepath.Join(dir, "data.jsonl") + `';
+			IMPORT T FROM '` + filepath.Join(dir, "data.txt") + `' FORMAT tsv COLUMNS (NAME, ID);
+			SPOOL ` + spool + `;
+			SELECT ID, COALESCE(NAME, 'null') FROM T ORDER BY ID;
+			SPOOL OFF;
+			ROLLBACK;`,
+		"data.csv":   "\xEF\xBB\xBFname,id\nfoo,1\nbar,x\n,2\n",
+		"data.jsonl": `{"id":3,"name":"baz"}` + "\n" + `{"ID":4}` + "\n" + `[5]` + "\n",
+		"data.txt":   "qux\t5\n",
+
+		"data.jsonl.reject": "old\n",
+	})
+	if err := runScriptFile(t, filepath.Join(dir, "main.sql")); err != nil {
+		t.Fatal(err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	expect := "1,foo|2,|3,baz|4,null|5,qux"
+	if got := strings.Join(lines, "|"); !strings.HasSuffix(got, expect) {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+
+	reject, err := os.ReadFile(filepath.Join(dir, "data.csv.reject"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if r := string(reject); !strings.HasPrefix(r, "3,") || !strings.HasSuffix(r, ",bar,x\n") {
+		t.Fatalf("unexpected reject file: %s", r)
+	}
+	if reject, err := os.ReadFile(filepath.Join(dir, "data.jsonl.reject")); err != nil || string(reject) != "old\n" {
+		t.Fatalf("the existing reject file was overwritten: %q %v", reject, err)
+	}
+	reject, err = os.ReadFile(filepath.Join(dir, "data.jsonl.reject.1"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if r := string(reject); !strings.HasPrefix(r, "3,") || !strings.HasSuffix(r, ",[5]\n") {
+		t.Fatalf("unexpected reject file: %s", r)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data.txt.reject")); err == nil {
+		t.Fatal("the reject file was created without rejected rows")
+	}
+}
+
+func TestImportInBatches(t *testing.T) {
+	sqlite := sqliteDialectForTest(t)
+	withSavepoint := *sqlite
+	withSavepoint.AbortsTransactionOnError = true
+	for _, d := range []*dialect.Entry{sqlite, &withSavepoint} {
+		testImportInBatches(t, d)
+	}
+}
+
+func testImportInBatches(t *testing.T, d *dialect.Entry) {
+	t.Helper()
+	restoreColor := disableColor()
+	defer restoreColor()
+
+	var data strings.Builder
+	data.WriteString("ID,NAME\n")
+	for i := 1; i <= 250; i++ {
+		id := i
+		if i == 120 {
+			id = 1 // rejected by the primary key
+		}
+		data.WriteString(strconv.Itoa(id) + ",name" + strconv.Itoa(i) + "\n")
+	}
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CREATE TABLE T ( ID INTEGER PRIMARY KEY, NAME CHAR VARYING(20) );
+			IMPORT T FROM '` + filepath.Join(dir, "data.csv") + `' HEADER;
+			SPOOL ` + spool + `;
+			SELECT COUNT(*), SUM(ID) FROM T;
+			SPOOL OFF;
+			COMMIT;`,
+		"data.csv": data.String(),
+	})
+	info, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cfg := New()
+	cfg.Script = filepath.Join(dir, "main.sql")
+	if err := cfg.Run(info.Driver, info.DataSource, d); err != nil {
+		t.Fatal(err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if expect := "249,31255"; !strings.Contains(string(output), expect) {
+		t.Fatalf("expect %s, but %s", expect, output)
+	}
+	reject, err := os.ReadFile(filepath.Join(dir, "data.csv.reject"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if r := string(reject); strings.Count(r, "\n") != 1 || !strings.HasPrefix(r, "121,") {
+		t.Fatalf("unexpected reject file: %s", r)
+	}
+}
+
+func TestImportXlsx(t *testing.T) {
+	dir := t.TempDir()
+	book := filepath.Join(dir, "book.xlsx")
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CREATE TABLE T ( ID INTEGER, NAME CHAR VARYING(20), BIRTH DATE );
+			INSERT INTO T VALUES (1, 'foo', '2001-02-03');
+			INSERT INTO T VALUES (2, NULL, NULL);
+			EXPORT TO '` + book + `' SELECT * FROM T ORDER BY ID;
+			CREATE TABLE T2 ( ID INTEGER, NAME CHAR VARYING(20), BIRTH CHAR VARYING(10) );
+			IMPORT T2 FROM '` + book + `' SHEET Sheet1 HEADER;
+			SPOOL ` + spool + `;
+			SELECT ID, COALESCE(NAME, 'null'), COALESCE(BIRTH, 'null') FROM T2 ORDER BY ID;
+			SPOOL OFF;
+			ROLLBACK;`,
+	})
+	if err := runScriptFile(t, filepath.Join(dir, "main.sql")); err != nil {
+		t.Fatal(err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	expect := "1,foo,2001-02-03|2,null,null"
+	if got := strings.Join(lines, "|"); !strings.HasSuffix(got, expect) {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+}
@@ -3,6 +3,7 @@ package sqlbless
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -19,7 +20,7 @@ import (
 	"github.com/hymkor/go-multiline-ny/completion"
 
 	"github.com/hymkor/sqlbless/internal/misc"
-	"github.com/hymkor/sqlbless/internal/sqlcompletion"
+	"github.com/hymkor/sqlbless/internal/sqllex"
 )
 
 type reserveWordPattern map[string]struct{}
@@ -49,21 +50,40 @@ func newReservedWordPattern(list ...string) reserveWordPattern {
 var o = struct{}{}
 
 var oneLineCommands = map[string]struct{}{
-	`COMMIT`:  o,
-	`DESC`:    o,
-	`EDIT`:    o,
-	`EXIT`:    o,
-	`HISTORY`: o,
-	`HOST`:    o,
-	`QUIT`:    o,
-	`REM`:     o,
-	`SPOOL`:   o,
-	`START`:   o,
-	`\D`:      o,
+	`ACCEPT`:      o,
+	`COMMIT`:      o,
+	`CONNECT`:     o,
+	`CONNECTIONS`: o,
+	`DEFINE`:      o,
+	`DESC`:        o,
+	`DISCONNECT`:  o,
+	`DUMP`:        o,
+	`EDIT`:        o,
+	`EXEC`:        o,
+	`EXECUTE`:     o,
+	`EXIT`:        o,
+	`HISTORY`:     o,
+	`HOST`:        o,
+	`IMPORT`:      o,
+	`PRINT`:       o,
+	`PROMPT`:      o,
+	`QUIT`:        o,
+	`REM`:         o,
+	`SPOOL`:       o,
+	`START`:       o,
+	`SWITCH`:      o,
+	`UNDEFINE`:    o,
+	`VAR`:         o,
+	`VARIABLE`:    o,
+	`WHENEVER`:    o,
+	`\D`:          o,
 }
 
 func isOneLineCommand(cmdLine string) bool {
 	first, _ := misc.CutField(cmdLine)
+	if strings.HasPrefix(first, "@") {
+		return true
+	}
 	first = strings.ToUpper(first)
 	first = strings.TrimRight(first, ";")
 	_, ok := oneLineCommands[first]
@@ -101,6 +121,17 @@ func (i *interactiveIn) Read(ctx context.Context) ([]string, error) {
 	return lines, nil
 }
 
+func (i *interactiveIn) ReadLine(ctx context.Context, prompt string) (string, error) {
+	editor := &readline.Editor{
+		Writer: i.Editor.Writer(),
+		PromptWriter: func(w io.Writer) (int, error) {
+			return io.WriteString(w, prompt)
+		},
+		Tty: i.Editor.LineEditor.Tty,
+	}
+	return editor.ReadLine(ctx)
+}
+
 func (i *interactiveIn) GetKey() (string, error) {
 	if err := i.tty.Open(nil); err != nil {
 		return "", err
@@ -121,7 +152,7 @@ func (ss *session) newInteractiveIn() *interactiveIn {
 		editor.ResetColor = "\x1B[0m"
 		editor.DefaultColor = "\x1B[39;49;1m"
 		editor.Highlight = []readline.Highlight{
-			{Pattern: newReservedWordPattern("HOST", "ALTER", "COMMIT", "CREATE", "DELETE", "DESC", "DROP", "EXIT", "HISTORY", "INSERT", "QUIT", "REM", "ROLLBACK", "SELECT", "SPOOL", "START", "TRUNCATE", "UPDATE", "AND", "FROM", "INTO", "OR", "WHERE", "SAVEPOINT", "TO", "SAVE", "TRANSACTION"), Sequence: "\x1B[36;49;1m"},
+			{Pattern: newReservedWordPattern("HOST", "ALTER", "COMMIT", "CREATE", "DELETE", "DESC", "DROP", "EXIT", "HISTORY", "INSERT", "QUIT", "REM", "ROLLBACK", "SELECT", "SPOOL", "START", "TRUNCATE", "UPDATE", "AND", "FROM", "INTO", "OR", "WHERE", "SAVEPOINT", "TO", "SAVE", "TRANSACTION", "VARIABLE", "PRINT", "EXEC", "DEFINE", "UNDEFINE", "ACCEPT", "EXPLAIN", "PROMPT", "WHENEVER", "IMPORT", "EXPORT", "DUMP", "COPY", "ATTACH", "STASH", "SCRATCH", "CONNECT", "CONNECTIONS", "DISCONNECT", "SWITCH"), Sequence: "\x1B[36;49;1m"},
 			{Pattern: regexp.MustCompile(`[0-9]+`), Sequence: "\x1B[35;49;1m"},
 			{Pattern: regexp.MustCompile(`/\*.*?\*/`), Sequence: "\x1B[33;49;22m"},
 			{Pattern: regexp.MustCompile(`"[^"]*"|"[^"]*$`), Sequence: "\x1B[31;49;1m"},
@@ -150,28 +181,27 @@ func (ss *session) newInteractiveIn() *interactiveIn {
 		tty = &tty8pe.Tty{}
 	}
 	editor.SetPredictColor(readline.PredictColorBlueItalic)
-	editor.SetHistory(ss.history)
+	editor.SetHistory(activeHistory{ss: ss})
 	editor.SetWriter(ss.termOut)
 
 	editor.BindKey(keys.CtrlI, &completion.CmdCompletionOrList{
 		Enclosure:         `"'`,
 		Delimiter:         ",",
 		Postfix:           " ",
-		CandidatesContext: sqlcompletion.New(ss.Dialect, ss.conn),
+		CandidatesContext: ss.complete,
 	})
 	editor.SubmitOnEnterWhen(func(lines []string, csrline int) bool {
 		if len(lines) > 0 && isOneLineCommand(lines[0]) {
 			return true
 		}
+		ok := sqllex.Complete(strings.Join(lines, "\n"), ss.Term, ss.Dialect.Syntax)
+		if !ok || len(ss.Term) == 1 {
+			return ok
+		}
 		for {
 			last := strings.TrimRight(lines[len(lines)-1], " \r\n\t\v")
 			if last != "" || len(lines) <= 1 {
-				if len(ss.Term) == 1 {
-					_, ok := misc.HasTerm(last, ss.Term)
-					return ok
-				} else {
-					return strings.EqualFold(last, ss.Term)
-				}
+				return strings.EqualFold(last, ss.Term)
 			}
 			lines = lines[:len(lines)-1]
 		}
@@ -18,25 +18,45 @@ type completeType struct {
 
 func getSqlCommands() []string {
 	return []string{
+		"accept",
 		"alter",
+		"attach",
 		"commit",
+		"connect",
+		"connections",
+		"copy",
+		"define",
 		"delete",
 		"desc",
+		"disconnect",
 		"drop",
+		"dump",
 		"edit",
+		"exec",
 		"exit",
+		"explain",
+		"export",
 		"history",
+		"import",
 		"insert",
+		"print",
+		"prompt",
 		"quit",
 		"rem",
 		"rollback",
 		"save",
 		"savepoint",
+		"scratch",
 		"select",
 		"spool",
 		"start",
+		"stash",
+		"switch",
 		"truncate",
+		"undefine",
 		"update",
+		"variable",
+		"whenever",
 	}
 }
 
@@ -47,7 +67,88 @@ func (C *completeType) getCandidates(ctx context.Context, fields []string) ([]st
 	lastKeywordAt := 0
 	var nextKeyword []string
 	for i, word := range fields {
-		if strings.EqualFold(word, "from") || strings.EqualFold(word, "edit") {
+		if strings.EqualFold(word, "from") && strings.EqualFold(fields[0], "import") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = []string{"format", "sheet", "header", "columns"}
+			candidates = func() []string {
+				v, _ := completion.PathComplete(fields)
+				return v
+			}
+		} else if strings.EqualFold(word, "to") && strings.EqualFold(fields[0], "export") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = []string{"format", "select"}
+			candidates = func() []string {
+				v, _ := completion.PathComplete(fields)
+				return v
+			}
+		} else if strings.EqualFold(word, "to") && strings.EqualFold(fields[0], "dump") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = []string{"constraints"}
+			candidates = func() []string {
+				v, _ := completion.PathComplete(fields)
+				return v
+			}
+		} else if strings.EqualFold(word, "csv") && strings.EqualFold(fields[0], "attach") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = []string{"as"}
+			candidates = func() []string {
+				v, _ := completion.PathComplete(fields)
+				return v
+			}
+		} else if strings.EqualFold(word, "scratch") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = nil
+			candidates = func() []string {
+				return []string{"select"}
+			}
+		} else if strings.EqualFold(word, "attach") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = nil
+			candidates = func() []string {
+				return []string{"csv"}
+			}
+		} else if strings.EqualFold(word, "from") && strings.EqualFold(fields[0], "copy") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = []string{"query"}
+			candidates = func() []string {
+				return nil
+			}
+		} else if strings.EqualFold(word, "to") && strings.EqualFold(fields[0], "copy") {
+			tableListNow = true
+			lastKeywordAt = i
+			nextKeyword = []string{"create", "append", "replace"}
+			candidates = func() []string {
+				return C.tables(ctx)
+			}
+		} else if strings.EqualFold(word, "query") && strings.EqualFold(fields[0], "copy") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = []string{"to"}
+			candidates = func() []string {
+				return nil
+			}
+		} else if strings.EqualFold(word, "copy") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = nil
+			candidates = func() []string {
+				return []string{"from"}
+			}
+		} else if strings.EqualFold(word, "export") {
+			tableListNow = false
+			lastKeywordAt = i
+			nextKeyword = nil
+			candidates = func() []string {
+				return []string{"to"}
+			}
+		} else if strings.EqualFold(word, "from") || strings.EqualFold(word, "edit") {
 			tableListNow = true
 			lastKeywordAt = i
 			nextKeyword = []string{"where"}
@@ -68,6 +169,20 @@ func (C *completeType) getCandidates(ctx context.Context, fields []string) ([]st
 			candidates = func() []string {
 				return C.columns(ctx, tableNameInline)
 			}
+		} else if strings.EqualFold(word, "dump") {
+			tableListNow = true
+			lastKeywordAt = i
+			nextKeyword = []string{"to"}
+			candidates = func() []string {
+				return C.tables(ctx)
+			}
+		} else if strings.EqualFold(word, "import") {
+			tableListNow = true
+			lastKeywordAt = i
+			nextKeyword = []string{"from"}
+			candidates = func() []string {
+				return C.tables(ctx)
+			}
 		} else if strings.EqualFold(word, "update") {
 			tableListNow = true
 			lastKeywordAt = i
@@ -0,0 +1,382 @@
+// Package sqllex splits SQL text into tokens so that terminators and
+// keywords inside strings, quoted identifiers and comments are ignored.
+package sqllex
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+type Kind int
+
+const (
+	Space Kind = iota
+	Comment
+	Quoted // string literals and quoted identifiers
+	Word   // keywords, identifiers and numbers
+	Symbol // any other character
+)
+
+type Token struct {
+	Kind Kind
+	Text string
+
+	// Pos is the byte offset of the token in the source text.
+	Pos int
+
+	// Unclosed is true when the quote or the comment is not closed
+	// at the end of the text.
+	Unclosed bool
+}
+
+type Lexer struct {
+	syntax dialect.Syntax
+	text   string
+	pos    int
+}
+
+func New(text string, syntax dialect.Syntax) *Lexer {
+	return &Lexer{syntax: syntax, text: text}
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func (L *Lexer) isWordChar(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c) || c == '$' || (c == '#' && !L.syntax.HashComment)
+}
+
+// quoted returns the end of the text enclosed with open and close
+// starting at from. A doubled close is an escaped one.
+func (L *Lexer) quoted(from int, close byte, backslash bool) (int, bool) {
+	for i := from; i < len(L.text); i++ {
+		c := L.text[i]
+		if backslash && c == '\\' {
+			i++
+			continue
+		}
+		if c != close {
+			continue
+		}
+		if i+1 < len(L.text) && L.text[i+1] == close {
+			i++
+			continue
+		}
+		return i + 1, true
+	}
+	return len(L.text), false
+}
+
+func (L *Lexer) blockComment(from int) (int, bool) {
+	depth := 1
+	for i := from; i+1 < len(L.text); i++ {
+		if L.text[i] == '*' && L.text[i+1] == '/' {
+			depth--
+			i++
+			if depth <= 0 {
+				return i + 1, true
+			}
+		} else if L.syntax.NestedComment && L.text[i] == '/' && L.text[i+1] == '*' {
+			depth++
+			i++
+		}
+	}
+	return len(L.text), false
+}
+
+// dollarTag returns `$tag$` starting at pos, or an empty string.
+func (L *Lexer) dollarTag(pos int) string {
+	i := pos + 1
+	if i < len(L.text) && L.text[i] != '$' {
+		if !isIdentifierStart(L.text[i]) {
+			return ""
+		}
+		for i < len(L.text) && (isIdentifierStart(L.text[i]) || isDigit(L.text[i])) {
+			i++
+		}
+	}
+	if i >= len(L.text) || L.text[i] != '$' {
+		return ""
+	}
+	return L.text[pos : i+1]
+}
+
+// Next returns the next token. It returns false at the end of the text.
+func (L *Lexer) Next() (Token, bool) {
+	start := L.pos
+	if start >= len(L.text) {
+		return Token{}, false
+	}
+	kind := Symbol
+	end := start + 1
+	closed := true
+	c := L.text[start]
+	next := byte(0)
+	if start+1 < len(L.text) {
+		next = L.text[start+1]
+	}
+	switch {
+	case isSpace(c):
+		kind = Space
+		for end < len(L.text) && isSpace(L.text[end]) {
+			end++
+		}
+	case (c == '-' && next == '-') || (c == '#' && L.syntax.HashComment):
+		kind = Comment
+		if i := strings.IndexByte(L.text[start:], '\n'); i >= 0 {
+			end = start + i
+		} else {
+			end = len(L.text)
+		}
+	case c == '/' && next == '*':
+		kind = Comment
+		end, closed = L.blockComment(start + 2)
+	case c == '\'':
+		kind = Quoted
+		end, closed = L.quoted(start+1, '\'', L.syntax.BackslashEscape)
+	case (c == 'E' || c == 'e') && next == '\'' && L.syntax.EscapeString:
+		kind = Quoted
+		end, closed = L.quoted(start+2, '\'', true)
+	case c == '"':
+		kind = Quoted
+		end, closed = L.quoted(start+1, '"', L.syntax.BackslashEscape)
+	case c == '`' && L.syntax.Backtick:
+		kind = Quoted
+		end, closed = L.quoted(start+1, '`', false)
+	case c == '[' && L.syntax.Bracket:
+		kind = Quoted
+		end, closed = L.quoted(start+1, ']', false)
+	case c == '$' && L.syntax.DollarQuote && L.dollarTag(start) != "":
+		kind = Quoted
+		tag := L.dollarTag(start)
+		if i := strings.Index(L.text[start+len(tag):], tag); i >= 0 {
+			end = start + len(tag) + i + len(tag)
+		} else {
+			end, closed = len(L.text), false
+		}
+	case isIdentifierStart(c) || isDigit(c):
+		kind = Word
+		for end < len(L.text) && L.isWordChar(L.text[end]) {
+			end++
+		}
+	default:
+		_, size := utf8.DecodeRuneInString(L.text[start:])
+		end = start + size
+	}
+	L.pos = end
+	return Token{Kind: kind, Text: L.text[start:end], Pos: start, Unclosed: !closed}, true
+}
+
+// Tokens returns all tokens of text.
+func Tokens(text string, syntax dialect.Syntax) []Token {
+	var tokens []Token
+	L := New(text, syntax)
+	for {
+		tk, ok := L.Next()
+		if !ok {
+			return tokens
+		}
+		tokens = append(tokens, tk)
+	}
+}
+
+// matchTerm reports whether the token tk at text[tk.Pos:] starts with term.
+// A term made of word characters has to be the whole word.
+func matchTerm(text string, tk Token, term string) bool {
+	switch tk.Kind {
+	case Word:
+		return strings.EqualFold(tk.Text, term)
+	case Symbol:
+		return len(text)-tk.Pos >= len(term) && strings.EqualFold(text[tk.Pos:tk.Pos+len(term)], term)
+	}
+	return false
+}
+
+// IndexTerm returns the index of the first term outside strings, quoted
+// identifiers and comments, or -1.
+func IndexTerm(text, term string, syntax dialect.Syntax) int {
+	if term == "" {
+		return -1
+	}
+	L := New(text, syntax)
+	for {
+		tk, ok := L.Next()
+		if !ok {
+			return -1
+		}
+		if matchTerm(text, tk, term) {
+			return tk.Pos
+		}
+	}
+}
+
+// HasTerm reports whether text ends with term outside strings, quoted
+// identifiers and comments, and returns text without the term.
+func HasTerm(text, term string, syntax dialect.Syntax) (string, bool) {
+	if term == "" {
+		return text, false
+	}
+	last := -1
+	L := New(text, syntax)
+	for {
+		tk, ok := L.Next()
+		if !ok {
+			break
+		}
+		if tk.Kind == Space {
+			continue
+		}
+		if tk.Unclosed {
+			return text, false
+		}
+		if last >= 0 && tk.Pos < last+len(term) {
+			continue
+		}
+		if matchTerm(text, tk, term) {
+			last = tk.Pos
+		} else {
+			last = -1
+		}
+	}
+	if last < 0 {
+		return text, false
+	}
+	return text[:last], true
+}
+
+// TrimLeft removes the spaces and the comments at the beginning of text.
+func TrimLeft(text string, syntax dialect.Syntax) string {
+	L := New(text, syntax)
+	for {
+		tk, ok := L.Next()
+		if !ok {
+			return ""
+		}
+		if tk.Kind != Space && tk.Kind != Comment {
+			return text[tk.Pos:]
+		}
+	}
+}
+
+// Keyword returns the first word of the statement skipping spaces,
+// comments and opening parentheses. When the statement starts with
+// a symbol such as `\D` or `@file`, the field up to the space is returned.
+func Keyword(text string, syntax dialect.Syntax) string {
+	L := New(text, syntax)
+	for {
+		tk, ok := L.Next()
+		if !ok {
+			return ""
+		}
+		switch tk.Kind {
+		case Space, Comment:
+			continue
+		case Word:
+			return tk.Text
+		case Symbol:
+			if tk.Text == "(" {
+				continue
+			}
+			rest := text[tk.Pos:]
+			if i := strings.IndexAny(rest, " \t\r\n\v\f"); i >= 0 {
+				return rest[:i]
+			}
+			return rest
+		}
+		return ""
+	}
+}
+
+// FirstTable returns the name of the first table following FROM outside
+// parentheses such as `schema.table` or `"quoted name"`. It returns an
+// empty string when FROM is not followed by a name.
+func FirstTable(text string, syntax dialect.Syntax) string {
+	depth := 0
+	afterFrom := false
+	start, end := -1, -1
+	L := New(text, syntax)
+	for {
+		tk, ok := L.Next()
+		if !ok {
+			break
+		}
+		if afterFrom {
+			if tk.Kind == Word || tk.Kind == Quoted || (tk.Kind == Symbol && tk.Text == "." && start >= 0) {
+				if start < 0 {
+					start = tk.Pos
+				}
+				end = tk.Pos + len(tk.Text)
+				continue
+			}
+			if start >= 0 || (tk.Kind != Space && tk.Kind != Comment) {
+				break
+			}
+			continue
+		}
+		switch {
+		case tk.Kind == Symbol && tk.Text == "(":
+			depth++
+		case tk.Kind == Symbol && tk.Text == ")":
+			depth--
+		case tk.Kind == Word && depth == 0 && strings.EqualFold(tk.Text, "FROM"):
+			afterFrom = true
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+	return text[start:end]
+}
+
+// StatementKeywords is similar with Keyword, but for the statement starting
+// with WITH, it returns the first words of the queries of the common table
+// expressions and the statement following them, such as SELECT or DELETE.
+func StatementKeywords(text string, syntax dialect.Syntax) []string {
+	keyword := Keyword(text, syntax)
+	if !strings.EqualFold(keyword, "WITH") {
+		return []string{keyword}
+	}
+	var keywords []string
+	depth := 0
+	afterAs := false
+	closed := false
+	L := New(text, syntax)
+	for {
+		tk, ok := L.Next()
+		if !ok {
+			// the statement is missing
+			return append(keywords, "")
+		}
+		switch {
+		case tk.Kind == Symbol && tk.Text == "(":
+			if depth == 0 && afterAs {
+				keywords = append(keywords, Keyword(text[tk.Pos+1:], syntax))
+				afterAs = false
+			}
+			depth++
+		case tk.Kind == Symbol && tk.Text == ")":
+			if depth--; depth == 0 {
+				closed = true
+			}
+		case depth > 0 || tk.Kind == Space || tk.Kind == Comment:
+		case tk.Kind == Symbol && tk.Text == ",":
+			closed = false
+		case tk.Kind == Word && strings.EqualFold(tk.Text, "AS"):
+			// after the list of the columns as `name (col,...) AS (...)`
+			afterAs = true
+		case tk.Kind == Word && closed && !afterAs:
+			return append(keywords, tk.Text)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package sqllex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+var (
+	postgres  = dialect.Syntax{DollarQuote: true, EscapeString: true, NestedComment: true}
+	mysql     = dialect.Syntax{Backtick: true, BackslashEscape: true, HashComment: true}
+	sqlServer = dialect.Syntax{Bracket: true}
+	standard  = dialect.Syntax{}
+)
+
+func TestIndexTerm(t *testing.T) {
+	for _, c := range []struct {
+		text   string
+		syntax dialect.Syntax
+		expect int
+	}{
+		{"SELECT 1; SELECT 2;", standard, 8},
+		{"SELECT 'a;b' ;", standard, 13},
+		{"SELECT 'it''s;' ;", standard, 16},
+		{"SELECT \"a;\" ;", standard, 12},
+		{"-- don't;\nSELECT 1;", standard, 18},
+		{"/* ; */ SELECT 1;", standard, 16},
+		{"/* /* ; */ ; */ SELECT 1;", postgres, 24},
+		{"/* /* ; */ ; */ SELECT 1;", standard, 11},
+		{"SELECT $$a;b$$ ;", postgres, 15},
+		{"SELECT $f$a;$$;b$f$ ;", postgres, 20},
+		{"SELECT $1 ;", postgres, 10},
+		{"SELECT E'\\';' ;", postgres, 14},
+		{"SELECT '\\';' ;", mysql, 13},
+		{"SELECT `a;b` ;", mysql, 13},
+		{"# ;\nSELECT 1;", mysql, 12},
+		{"SELECT [a;b] ;", sqlServer, 13},
+		{"SELECT 'unclosed;", standard, -1},
+	} {
+		if got := IndexTerm(c.text, ";", c.syntax); got != c.expect {
+			t.Errorf("IndexTerm(%q): expect %d, but %d", c.text, c.expect, got)
+		}
+	}
+}
+
+func TestHasTerm(t *testing.T) {
+	for _, c := range []struct {
+		text   string
+		term   string
+		expect bool
+	}{
+		{"SELECT 1;", ";", true},
+		{"SELECT 1 ; \n", ";", true},
+		{"SELECT ';", ";", false},
+		{"SELECT 1 -- ;", ";", false},
+		{"SELECT 1\nGO", "GO", true},
+		{"SELECT GOAL", "GO", false},
+		{"SELECT 1 //", "//", true},
+	} {
+		if _, ok := HasTerm(c.text, c.term, standard); ok != c.expect {
+			t.Errorf("HasTerm(%q,%q): expect %v, but %v", c.text, c.term, c.expect, ok)
+		}
+	}
+	if s, _ := HasTerm("SELECT 1 ;", ";", standard); s != "SELECT 1 " {
+		t.Errorf("HasTerm: expect %q, but %q", "SELECT 1 ", s)
+	}
+}
+
+func TestKeyword(t *testing.T) {
+	for _, c := range []struct {
+		text   string
+		expect string
+	}{
+		{"SELECT 1", "SELECT"},
+		{"  /* hint */ select 1", "select"},
+		{"-- comment\n\tUPDATE T SET A=1", "UPDATE"},
+		{"((SELECT 1) UNION (SELECT 2))", "SELECT"},
+		{`\D table`, `\D`},
+		{"@script.sql arg", "@script.sql"},
+		{"-- only comment", ""},
+	} {
+		if got := Keyword(c.text, standard); got != c.expect {
+			t.Errorf("Keyword(%q): expect %q, but %q", c.text, c.expect, got)
+		}
+	}
+}
+
+func TestStatementKeywords(t *testing.T) {
+	for _, c := range []struct {
+		text   string
+		expect string
+	}{
+		{"SELECT 1", "SELECT"},
+		{"WITH A AS (SELECT 1) SELECT * FROM A", "SELECT,SELECT"},
+		{"with a (x) as (select 1), b as (select ')' from a) delete from t", "select,select,delete"},
+		{"WITH RECURSIVE A AS ((SELECT 1) UNION ALL SELECT 1 FROM A) -- ( \n UPDATE T SET X=1", "SELECT,UPDATE"},
+		{"WITH A AS NOT MATERIALIZED (DELETE FROM T RETURNING *) SELECT * FROM A", "DELETE,SELECT"},
+		{"WITH A AS (SELECT 1)", "SELECT,"},
+	} {
+		if got := strings.Join(StatementKeywords(c.text, standard), ","); got != c.expect {
+			t.Errorf("StatementKeywords(%q): expect %q, but %q", c.text, c.expect, got)
+		}
+	}
+}
+
+func TestFirstTable(t *testing.T) {
+	for _, c := range []struct {
+		text   string
+		expect string
+	}{
+		{"SELECT * FROM T", "T"},
+		{"select a from sch.tbl where a=1", "sch.tbl"},
+		{`SELECT (SELECT 1 FROM DUAL) FROM "my table" x`, `"my table"`},
+		{"SELECT * FROM /* comment */ T1, T2", "T1"},
+		{"SELECT * FROM (SELECT 1)", ""},
+		{"SELECT 1", ""},
+	} {
+		if got := FirstTable(c.text, standard); got != c.expect {
+			t.Errorf("FirstTable(%q): expect %q, but %q", c.text, c.expect, got)
+		}
+	}
+}
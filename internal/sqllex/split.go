@@ -0,0 +1,171 @@
+package sqllex
+
+import (
+	"strings"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+// words returns the upper-cased first n words of text skipping spaces and
+// comments. It stops at the first token which is not a word.
+func words(text string, syntax dialect.Syntax, n int) []string {
+	var result []string
+	L := New(text, syntax)
+	for len(result) < n {
+		tk, ok := L.Next()
+		if !ok || (tk.Kind != Space && tk.Kind != Comment && tk.Kind != Word) {
+			break
+		}
+		if tk.Kind == Word {
+			result = append(result, strings.ToUpper(tk.Text))
+		}
+	}
+	return result
+}
+
+// isTransactionBegin reports whether the words following BEGIN make
+// the statement to start a transaction.
+func isTransactionBegin(next []string) bool {
+	if len(next) <= 0 {
+		return true
+	}
+	switch next[0] {
+	case "TRANSACTION", "TRAN", "WORK", "DISTRIBUTED",
+		"DEFERRED", "IMMEDIATE", "EXCLUSIVE",
+		"ISOLATION", "READ", "NOT":
+		return true
+	}
+	return false
+}
+
+// IsBlock reports whether the statement is a procedural block such as
+// BEGIN ... END, or a CREATE statement which can contain one.
+// `BEGIN`, `BEGIN TRANSACTION` and so on are not blocks.
+func IsBlock(text string, syntax dialect.Syntax) bool {
+	w := words(text, syntax, 6)
+	if len(w) <= 0 {
+		return false
+	}
+	switch w[0] {
+	case "BEGIN":
+		return !isTransactionBegin(w[1:])
+	case "DECLARE":
+		return syntax.SlashTerminatesBlocks
+	case "CREATE":
+		for _, word := range w[1:] {
+			switch word {
+			case "OR", "REPLACE", "EDITIONABLE", "NONEDITIONABLE", "TEMP", "TEMPORARY":
+				continue
+			case "FUNCTION", "PROCEDURE", "TRIGGER", "PACKAGE", "TYPE":
+				return true
+			}
+			return false
+		}
+	}
+	return false
+}
+
+// IsAnonymousBlock reports whether the statement is BEGIN ... END or
+// DECLARE ... BEGIN ... END, which is executed instead of being created.
+func IsAnonymousBlock(text string, syntax dialect.Syntax) bool {
+	w := words(text, syntax, 1)
+	return len(w) > 0 && (w[0] == "BEGIN" || w[0] == "DECLARE") && IsBlock(text, syntax)
+}
+
+// isSlashLine reports whether tk is `/` alone on its line.
+func isSlashLine(text string, tk Token) bool {
+	if tk.Kind != Symbol || tk.Text != "/" {
+		return false
+	}
+	lineStart := strings.LastIndexByte(text[:tk.Pos], '\n') + 1
+	if strings.TrimSpace(text[lineStart:tk.Pos]) != "" {
+		return false
+	}
+	rest := text[tk.Pos+1:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i]
+	}
+	return strings.TrimSpace(rest) == ""
+}
+
+// Split returns the length of the first statement of text including its
+// terminator, or -1 when the statement is not complete yet. A statement is
+// terminated by term outside strings, quoted identifiers and comments, or
+// by a line containing only `/`. In a block, term is not a terminator
+// until BEGIN (or CASE) and END are balanced.
+func Split(text, term string, syntax dialect.Syntax) int {
+	block := IsBlock(text, syntax)
+	slashOnly := block && syntax.SlashTerminatesBlocks
+	depth := 0
+	var prev string
+	L := New(text, syntax)
+	for {
+		tk, ok := L.Next()
+		if !ok {
+			return -1
+		}
+		if isSlashLine(text, tk) {
+			return tk.Pos + 1
+		}
+		if tk.Kind == Space || tk.Kind == Comment {
+			continue
+		}
+		word := ""
+		if tk.Kind == Word {
+			word = strings.ToUpper(tk.Text)
+		}
+		if block {
+			switch prev {
+			case "BEGIN":
+				if !isTransactionBegin([]string{word}) {
+					depth++
+				}
+			case "END":
+				switch word {
+				case "IF", "LOOP", "WHILE", "REPEAT", "FOR":
+				default:
+					if depth > 0 {
+						depth--
+					}
+				}
+			}
+			if word == "CASE" && prev != "END" {
+				depth++
+			}
+		}
+		prev = word
+		if !slashOnly && depth <= 0 && matchTerm(text, tk, term) {
+			return tk.Pos + len(term)
+		}
+	}
+}
+
+// Complete reports whether all statements in text are terminated.
+func Complete(text, term string, syntax dialect.Syntax) bool {
+	for {
+		n := Split(text, term, syntax)
+		if n < 0 {
+			return false
+		}
+		text = text[n:]
+		if strings.TrimSpace(text) == "" {
+			return true
+		}
+	}
+}
+
+// TrimTerm removes the terminator at the end of the statement returned
+// by Split. A line containing only `/` is removed, but the last `;` of
+// a block is kept when SlashTerminatesBlocks is set, because it is a part
+// of the block.
+func TrimTerm(text, term string, syntax dialect.Syntax) (string, bool) {
+	trimmed := strings.TrimRight(text, " \t\r\n\v\f")
+	lineStart := strings.LastIndexByte(trimmed, '\n') + 1
+	if strings.TrimSpace(trimmed[lineStart:]) == "/" {
+		return trimmed[:lineStart], true
+	}
+	if syntax.SlashTerminatesBlocks && IsBlock(text, syntax) {
+		return text, false
+	}
+	return HasTerm(text, term, syntax)
+}
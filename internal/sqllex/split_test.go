@@ -0,0 +1,105 @@
+package sqllex
+
+import (
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+var oracle = dialect.Syntax{SlashTerminatesBlocks: true}
+
+func TestIsBlock(t *testing.T) {
+	for _, c := range []struct {
+		text   string
+		syntax dialect.Syntax
+		expect bool
+	}{
+		{"BEGIN", standard, false},
+		{"BEGIN;", standard, false},
+		{"BEGIN TRANSACTION;", standard, false},
+		{"begin work", standard, false},
+		{"BEGIN IMMEDIATE;", standard, false},
+		{"BEGIN NULL; END;", standard, true},
+		{"BEGIN TRY SELECT 1; END TRY", sqlServer, true},
+		{"DECLARE X NUMBER; BEGIN NULL; END;", oracle, true},
+		{"DECLARE C CURSOR FOR SELECT 1;", postgres, false},
+		{"CREATE OR REPLACE PROCEDURE P IS BEGIN NULL; END;", oracle, true},
+		{"CREATE TEMP TRIGGER T AFTER INSERT ON X BEGIN SELECT 1; END;", standard, true},
+		{"CREATE TABLE T (A INT);", standard, false},
+	} {
+		if got := IsBlock(c.text, c.syntax); got != c.expect {
+			t.Errorf("IsBlock(%q): expect %v, but %v", c.text, c.expect, got)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, c := range []struct {
+		text   string
+		syntax dialect.Syntax
+		expect string
+	}{
+		{"SELECT 1; SELECT 2;", standard, "SELECT 1;"},
+		{"BEGIN; SELECT 2;", standard, "BEGIN;"},
+		{
+			"CREATE TRIGGER T AFTER INSERT ON X BEGIN UPDATE Y SET A=1; DELETE FROM Z; END; SELECT 1;",
+			standard,
+			"CREATE TRIGGER T AFTER INSERT ON X BEGIN UPDATE Y SET A=1; DELETE FROM Z; END;",
+		},
+		{
+			"CREATE PROCEDURE P() BEGIN IF 1 THEN SELECT CASE WHEN 1 THEN 2 END; END IF; END; SELECT 1;",
+			mysql,
+			"CREATE PROCEDURE P() BEGIN IF 1 THEN SELECT CASE WHEN 1 THEN 2 END; END IF; END;",
+		},
+		{
+			"BEGIN TRY BEGIN TRANSACTION; SELECT 1; END TRY BEGIN CATCH SELECT 2; END CATCH; SELECT 3;",
+			sqlServer,
+			"BEGIN TRY BEGIN TRANSACTION; SELECT 1; END TRY BEGIN CATCH SELECT 2; END CATCH;",
+		},
+		{
+			"CREATE FUNCTION F() RETURNS INT AS $$ BEGIN RETURN 1; END $$ LANGUAGE plpgsql; SELECT 1;",
+			postgres,
+			"CREATE FUNCTION F() RETURNS INT AS $$ BEGIN RETURN 1; END $$ LANGUAGE plpgsql;",
+		},
+		{
+			"DECLARE\n  X NUMBER;\nBEGIN\n  NULL;\nEND;\n/\nSELECT 1;",
+			oracle,
+			"DECLARE\n  X NUMBER;\nBEGIN\n  NULL;\nEND;\n/",
+		},
+		{
+			"CREATE PACKAGE BODY K AS\n PROCEDURE P IS BEGIN NULL; END;\nEND K;\n/\n",
+			oracle,
+			"CREATE PACKAGE BODY K AS\n PROCEDURE P IS BEGIN NULL; END;\nEND K;\n/",
+		},
+		{"SELECT 1\n/\n", standard, "SELECT 1\n/"},
+		{"SELECT 4\n/ 2;", standard, "SELECT 4\n/ 2;"},
+		{"BEGIN NULL; END", standard, ""},
+		{"DECLARE X NUMBER; BEGIN NULL; END;", oracle, ""},
+	} {
+		n := Split(c.text, ";", c.syntax)
+		got := ""
+		if n >= 0 {
+			got = c.text[:n]
+		}
+		if got != c.expect {
+			t.Errorf("Split(%q): expect %q, but %q", c.text, c.expect, got)
+		}
+	}
+}
+
+func TestTrimTerm(t *testing.T) {
+	for _, c := range []struct {
+		text   string
+		syntax dialect.Syntax
+		expect string
+	}{
+		{"SELECT 1;", standard, "SELECT 1"},
+		{"SELECT 1\n/", standard, "SELECT 1\n"},
+		{"BEGIN NULL; END;", standard, "BEGIN NULL; END"},
+		{"BEGIN NULL; END;\n/", oracle, "BEGIN NULL; END;\n"},
+	} {
+		if got, _ := TrimTerm(c.text, ";", c.syntax); got != c.expect {
+			t.Errorf("TrimTerm(%q): expect %q, but %q", c.text, c.expect, got)
+		}
+	}
+}
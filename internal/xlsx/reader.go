@@ -0,0 +1,270 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSheetNotFound = errors.New("sheet not found")
+	ErrNoSheets      = errors.New("the workbook has no sheets")
+)
+
+// Row is a row of a worksheet. Number is the row number starting from 1.
+type Row struct {
+	Number int
+	Cells  []string
+}
+
+type xmlText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (x *xmlText) String() string {
+	if len(x.Runs) <= 0 {
+		return x.T
+	}
+	var b strings.Builder
+	b.WriteString(x.T)
+	for _, r := range x.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xmlWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xmlSharedStrings struct {
+	Items []xmlText `xml:"si"`
+}
+
+type xmlStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xmlWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string  `xml:"r,attr"`
+			T  string  `xml:"t,attr"`
+			S  int     `xml:"s,attr"`
+			V  string  `xml:"v"`
+			Is xmlText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXML(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := xml.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+var rxQuotedInFormat = regexp.MustCompile(`"[^"]*"|\[[^\]]*\]|\\.`)
+
+// isDateFormat reports whether the number format shows dates or times.
+func isDateFormat(id int, code string) bool {
+	if (14 <= id && id <= 22) || (45 <= id && id <= 47) {
+		return true
+	}
+	code = rxQuotedInFormat.ReplaceAllString(code, "")
+	return strings.ContainsAny(strings.ToLower(code), "ymdhs")
+}
+
+// columnIndex returns the index of the column of the cell reference such as B3.
+func columnIndex(ref string) int {
+	index := 0
+	for _, c := range ref {
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		index = index*26 + int(c-'A'+1)
+	}
+	return index - 1
+}
+
+// dateText returns the text of the serial number of Excel.
+func dateText(value string) string {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	ms := int64(math.Round(f * 86400000))
+	t := excelEpoch.Add(time.Duration(ms%86400000)*time.Millisecond).AddDate(0, 0, int(ms/86400000))
+	if ms%86400000 == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05.999")
+}
+
+// ReadSheet returns the rows of the sheet named sheetName, or the first
+// sheet when sheetName is empty. Empty cells are filled with blank so
+// that all rows have the same number of cells. Dates are returned as
+// texts like 2006-01-02 15:04:05.
+func ReadSheet(r io.ReaderAt, size int64, sheetName, blank string) ([]Row, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+	var workbook xmlWorkbook
+	if err := readXML(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) <= 0 {
+		return nil, ErrNoSheets
+	}
+	sheetIndex := 0
+	if sheetName != "" {
+		sheetIndex = -1
+		for i, s := range workbook.Sheets {
+			if strings.EqualFold(s.Name, sheetName) {
+				sheetIndex = i
+				break
+			}
+		}
+		if sheetIndex < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrSheetNotFound, sheetName)
+		}
+	}
+	var rels xmlRelationships
+	if err := readXML(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	target := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[sheetIndex].ID {
+			target = rel.Target
+		}
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var shared xmlSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := readXML(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	var dateStyles []bool
+	if _, ok := files["xl/styles.xml"]; ok {
+		var styles xmlStyles
+		if err := readXML(files, "xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+		codes := map[int]string{}
+		for _, f := range styles.NumFmts {
+			codes[f.ID] = f.Code
+		}
+		for _, xf := range styles.CellXfs {
+			dateStyles = append(dateStyles, isDateFormat(xf.NumFmtID, codes[xf.NumFmtID]))
+		}
+	}
+
+	var sheet xmlWorksheet
+	if err := readXML(files, target, &sheet); err != nil {
+		return nil, err
+	}
+	rows := make([]Row, 0, len(sheet.Rows))
+	width := 0
+	for i, xr := range sheet.Rows {
+		row := Row{Number: xr.R}
+		if row.Number <= 0 {
+			row.Number = i + 1
+		}
+		col := 0
+		for _, c := range xr.Cells {
+			if c.R != "" {
+				col = columnIndex(c.R)
+			}
+			for len(row.Cells) <= col {
+				row.Cells = append(row.Cells, blank)
+			}
+			var value string
+			switch c.T {
+			case "s":
+				if n, err := strconv.Atoi(c.V); err == nil && n >= 0 && n < len(shared.Items) {
+					value = shared.Items[n].String()
+				}
+			case "inlineStr":
+				value = c.Is.String()
+			case "b":
+				if c.V == "1" {
+					value = "TRUE"
+				} else {
+					value = "FALSE"
+				}
+			case "", "n":
+				value = c.V
+				if c.S >= 0 && c.S < len(dateStyles) && dateStyles[c.S] {
+					value = dateText(c.V)
+				}
+			default:
+				value = c.V
+			}
+			row.Cells[col] = value
+			col++
+		}
+		if len(row.Cells) > width {
+			width = len(row.Cells)
+		}
+		rows = append(rows, row)
+	}
+	for i := range rows {
+		for len(rows[i].Cells) < width {
+			rows[i].Cells = append(rows[i].Cells, blank)
+		}
+	}
+	return rows, nil
+}
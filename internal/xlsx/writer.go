@@ -0,0 +1,201 @@
+// Package xlsx writes and reads the worksheets of Excel .xlsx files
+// without cgo. Only the cell values and the formats of dates are handled.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Number is a number written as a text such as DECIMAL values.
+// It is written as a numeric cell without conversion.
+type Number string
+
+const (
+	styleDefault  = 0
+	styleDateTime = 1
+	styleDate     = 2
+)
+
+const contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const workbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// stylesXML defines the styles: 0 is the default, 1 is a timestamp and 2 is a date.
+const stylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd"/></numFmts>
+<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>
+</styleSheet>`
+
+const sheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const sheetFooter = `</sheetData></worksheet>`
+
+// Writer writes a workbook with a worksheet row by row.
+type Writer struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func writeEntry(zw *zip.Writer, name, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, content)
+	return err
+}
+
+// NewWriter starts a workbook with the worksheet named sheetName.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	for _, entry := range []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", fmt.Sprintf(workbookXML, escape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+		{"xl/styles.xml", stylesXML},
+	} {
+		if err := writeEntry(zw, entry.name, entry.content); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	bw := bufio.NewWriter(sheet)
+	bw.WriteString(sheetHeader)
+	return &Writer{zw: zw, sheet: bw}, nil
+}
+
+// ColumnName returns the name of the column such as A, B, ..., Z, AA
+// for the index starting from 0.
+func ColumnName(i int) string {
+	var name []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = append([]byte{byte('A' + (i-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// serial returns the serial number of Excel for the wall clock of t.
+func serial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	seconds := wall.Unix() - excelEpoch.Unix()
+	return float64(seconds)/86400 + float64(wall.Nanosecond())/86400e9
+}
+
+func writeNumber(b *bufio.Writer, ref string, style int, value string) {
+	if style == styleDefault {
+		fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, value)
+	} else {
+		fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, value)
+	}
+}
+
+func writeText(b *bufio.Writer, ref, value string) {
+	space := ""
+	if strings.TrimSpace(value) != value {
+		space = ` xml:space="preserve"`
+	}
+	fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t%s>%s</t></is></c>`, ref, space, escape(value))
+}
+
+// WriteRow writes a row. nil is an empty cell. Numbers, Number, booleans
+// and time.Time are typed cells and the others are texts.
+func (w *Writer) WriteRow(values []any) error {
+	w.row++
+	b := w.sheet
+	fmt.Fprintf(b, `<row r="%d">`, w.row)
+	for i, v := range values {
+		ref := ColumnName(i) + strconv.Itoa(w.row)
+		switch x := v.(type) {
+		case nil:
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			writeNumber(b, ref, styleDefault, fmt.Sprint(x))
+		case float32:
+			writeNumber(b, ref, styleDefault, strconv.FormatFloat(float64(x), 'g', -1, 32))
+		case float64:
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				writeText(b, ref, strconv.FormatFloat(x, 'g', -1, 64))
+			} else {
+				writeNumber(b, ref, styleDefault, strconv.FormatFloat(x, 'g', -1, 64))
+			}
+		case Number:
+			writeNumber(b, ref, styleDefault, string(x))
+		case bool:
+			value := "0"
+			if x {
+				value = "1"
+			}
+			fmt.Fprintf(b, `<c r="%s" t="b"><v>%s</v></c>`, ref, value)
+		case time.Time:
+			style := styleDateTime
+			if x.Hour() == 0 && x.Minute() == 0 && x.Second() == 0 && x.Nanosecond() == 0 {
+				style = styleDate
+			}
+			writeNumber(b, ref, style, strconv.FormatFloat(serial(x), 'f', -1, 64))
+		case []byte:
+			writeText(b, ref, string(x))
+		case string:
+			writeText(b, ref, x)
+		default:
+			writeText(b, ref, fmt.Sprint(x))
+		}
+	}
+	_, err := b.WriteString(`</row>`)
+	return err
+}
+
+// Close completes the workbook. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	w.sheet.WriteString(sheetFooter)
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
@@ -0,0 +1,57 @@
+package xlsx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestColumnName(t *testing.T) {
+	for i, expect := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := ColumnName(i); got != expect {
+			t.Errorf("ColumnName(%d): expect %s, but %s", i, expect, got)
+		}
+		if got := columnIndex(expect + "12"); got != i {
+			t.Errorf("columnIndex(%s12): expect %d, but %d", expect, i, got)
+		}
+	}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	var buffer bytes.Buffer
+	w, err := NewWriter(&buffer, "Data & More")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	stamp := time.Date(2024, 5, 25, 13, 45, 33, 0, time.UTC)
+	day := time.Date(2024, 5, 25, 0, 0, 0, 0, time.Local)
+	for _, row := range [][]any{
+		{"ID", "CODE", "AMOUNT", "STAMP", "NOTE"},
+		{int64(1), "007", Number("12.50"), stamp, " <a&b> "},
+		{int64(2), nil, 3.5, day, true},
+	} {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	data := buffer.Bytes()
+	rows, err := ReadSheet(bytes.NewReader(data), int64(len(data)), "data & more", "NULL")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := []Row{
+		{Number: 1, Cells: []string{"ID", "CODE", "AMOUNT", "STAMP", "NOTE"}},
+		{Number: 2, Cells: []string{"1", "007", "12.50", "2024-05-25 13:45:33", " <a&b> "}},
+		{Number: 3, Cells: []string{"2", "NULL", "3.5", "2024-05-25", "TRUE"}},
+	}
+	if !reflect.DeepEqual(rows, expect) {
+		t.Fatalf("expect %#v, but %#v", expect, rows)
+	}
+	if _, err := ReadSheet(bytes.NewReader(data), int64(len(data)), "Sheet9", ""); err == nil {
+		t.Fatal("ErrSheetNotFound is expected")
+	}
+}
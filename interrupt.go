@@ -0,0 +1,120 @@
+package sqlbless
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/internal/sqllex"
+)
+
+// interrupter cancels the statement running now when SIGINT is received,
+// instead of terminating the process.
+type interrupter struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+func (i *interrupter) interrupt() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.cancel != nil {
+		i.cancel()
+	}
+}
+
+// startInterrupter starts to catch SIGINT. The returned function stops it.
+func (ss *session) startInterrupter() func() {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, os.Interrupt)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				ss.interrupter.interrupt()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// statementContext returns the context for a statement which is cancelled by SIGINT.
+// For nested statements (e.g. in the script by START), only the outermost one
+// is registered because the others are derived from it.
+func (ss *session) statementContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	i := &ss.interrupter
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.cancel != nil {
+		return ctx, cancel
+	}
+	i.cancel = cancel
+	return ctx, func() {
+		i.mu.Lock()
+		i.cancel = nil
+		i.mu.Unlock()
+		cancel()
+	}
+}
+
+const cancelSavepoint = "sqlbless_cancel"
+
+// needsNoSavepoint reports whether the statement is a command of SQL-Bless
+// which sends nothing to the server, or a command on savepoints which
+// should not be mixed with the savepoint for cancellation.
+func needsNoSavepoint(cmd, arg string) bool {
+	switch strings.ToUpper(cmd) {
+	case "COMMIT", "ROLLBACK", "SAVEPOINT", "SAVE", "RELEASE", "CONNECT":
+		return true
+	case "START":
+		// The statements of the script are sent to the server.
+		return false
+	}
+	return !strings.HasPrefix(cmd, "@") && isClientCommand(cmd, arg)
+}
+
+// protectTransaction sets a savepoint before a statement sent to the
+// server when the dialect can not continue the transaction after an error.
+// The returned function must be called after the statement and rolls back
+// to the savepoint when the statement was cancelled. The savepoint is
+// released only when the statement did not set savepoints of the user,
+// since releasing it also releases the ones set after it.
+func (ss *session) protectTransaction(query string) func(cancelled bool) {
+	tx := ss.tx
+	if tx == nil || !ss.Dialect.AbortsTransactionOnError {
+		return func(bool) {}
+	}
+	cmd, arg := misc.CutField(sqllex.TrimLeft(query, ss.Dialect.Syntax))
+	if needsNoSavepoint(cmd, arg) {
+		return func(bool) {}
+	}
+	ctx := context.Background()
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+cancelSavepoint); err != nil {
+		return func(bool) {}
+	}
+	savepoints := ss.savepoints
+	return func(cancelled bool) {
+		if ss.tx != tx {
+			return
+		}
+		if cancelled {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+cancelSavepoint); err == nil {
+				fmt.Fprintln(ss.stdErr, "Rolled back to the point before the cancelled statement.")
+			}
+		}
+		if ss.savepoints == savepoints {
+			tx.ExecContext(ctx, "RELEASE SAVEPOINT "+cancelSavepoint)
+		}
+	}
+}
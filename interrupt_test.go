@@ -0,0 +1,79 @@
+package sqlbless
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func TestStatementContext(t *testing.T) {
+	ss := &session{}
+
+	outer, cancelOuter := ss.statementContext(context.Background())
+	inner, cancelInner := ss.statementContext(outer)
+	cancelInner()
+	if outer.Err() != nil {
+		t.Fatal("the outer statement was cancelled by the end of the inner one")
+	}
+
+	inner, cancelInner = ss.statementContext(outer)
+	defer cancelInner()
+	ss.interrupter.interrupt()
+	if outer.Err() == nil || inner.Err() == nil {
+		t.Fatal("statements were not cancelled by the interrupt")
+	}
+	cancelOuter()
+
+	next, cancelNext := ss.statementContext(context.Background())
+	defer cancelNext()
+	if next.Err() != nil {
+		t.Fatal("the next statement was cancelled")
+	}
+	if ss.interrupter.cancel == nil {
+		t.Fatal("the next statement was not registered")
+	}
+}
+
+func TestProtectTransactionKeepsUserSavepoints(t *testing.T) {
+	d, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	db, err := sql.Open(d.Driver, d.DataSource)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer db.Close()
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer tx.Rollback()
+
+	aborting := *d.Dialect
+	aborting.AbortsTransactionOnError = true
+	ss := &session{Config: New(), Dialect: &aborting, tx: tx}
+
+	// No savepoint is set for the commands of savepoints and the client.
+	for _, query := range []string{"ROLLBACK TO a", "RELEASE a", "PRINT", "DEFINE X = 1"} {
+		afterStatement := ss.protectTransaction(query)
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+cancelSavepoint); err == nil {
+			t.Fatalf("%s: a savepoint was set", query)
+		}
+		afterStatement(false)
+	}
+
+	// The savepoint of the user set in a script is not released.
+	afterStatement := ss.protectTransaction("START sub.sql")
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT a"); err != nil {
+		t.Fatal(err.Error())
+	}
+	ss.savepoints++
+	afterStatement(false)
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT a"); err != nil {
+		t.Fatalf("the savepoint of the user was lost: %s", err.Error())
+	}
+}
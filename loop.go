@@ -23,6 +23,7 @@ import (
 	"github.com/hymkor/sqlbless/internal/history"
 	"github.com/hymkor/sqlbless/internal/lftocrlf"
 	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/internal/sqllex"
 )
 
 type commandIn interface {
@@ -37,6 +38,9 @@ type commandIn interface {
 	ShouldRecordHistory() bool
 	SetPrompt(func(io.Writer, int) (int, error))
 	OnErrorAbort() bool
+
+	// ReadLine asks the user for a single line of text with prompt.
+	ReadLine(ctx context.Context, prompt string) (string, error)
 }
 
 type session struct {
@@ -45,14 +49,35 @@ type session struct {
 	conn            *sql.Conn
 	history         *history.History
 	tx              *sql.Tx
+	scratch         *scratchDB
+	connections     []*connection
+	current         *connection
+	bindVars        map[string]*bindVariable
+	defines         map[string]string
+	noDefine        bool
+	interrupter     interrupter
+	timeout         time.Duration
+	deadline        *time.Timer
+	savepoints      int
+	keepAlive       time.Duration
+	setup           []string
+	scripts         []string
+	whenever        *sqlErrorAction
+	exiting         bool
+	echoOff         bool
+	format          string
+	outFormat       string
+	resultOut       io.Writer
 	spool           lftocrlf.WriteNameCloser
 	stdOut, termOut io.Writer
 	stdErr, termErr io.Writer
 }
 
 func (ss *session) Close() {
-	if ss.tx != nil {
-		ss.rollback()
+	ss.closeConnections()
+	if ss.scratch != nil {
+		ss.scratch.Close()
+		ss.scratch = nil
 	}
 	if ss.spool != nil {
 		ss.spool.Close()
@@ -73,10 +98,20 @@ var (
 	ErrNotSupported           = errors.New("not supported")
 	ErrInvalidRollback        = errors.New("invalid ROLLBACK syntax: expected 'TO' or 'TRANSACTION'")
 	ErrNoActiveTransaction    = errors.New("no active transaction")
+	ErrCanNotAskValue         = errors.New("can not ask a value without a terminal")
+	ErrNoStatement            = errors.New("no statement is given")
 )
 
 func (ss *session) prompt(w io.Writer, i int) (int, error) {
 	io.WriteString(w, "\x1B[0m")
+	if ss.current != nil && ss.current.color != "" {
+		// The color of the profile such as red for production
+		io.WriteString(w, ss.current.color)
+		defer io.WriteString(w, "\x1B[0m")
+	}
+	if i <= 0 && len(ss.connections) > 1 {
+		fmt.Fprintf(w, "%s ", ss.current.name)
+	}
 	if i <= 0 {
 		if ss.tx != nil {
 			return io.WriteString(w, "SQL* ")
@@ -95,11 +130,13 @@ func (ss *session) Loop(ctx context.Context, commandIn commandIn) error {
 		if ss.spool != nil {
 			fmt.Fprintf(ss.termErr, "\nSpooling to '%s' now\n", ss.spool.Name())
 		}
+		stopKeepAlive := ss.startKeepAlive()
 		lines, err := commandIn.Read(ctx)
+		stopKeepAlive()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
-				if ss.tx != nil && !commandIn.CanCloseInTransaction() {
-					fmt.Fprintln(ss.termErr, ErrTransactionIsNotClosed.Error())
+				if names := ss.openTransactions(); len(names) > 0 && !commandIn.CanCloseInTransaction() {
+					fmt.Fprintln(ss.termErr, ss.errTransactionsNotClosed(names).Error())
 					continue
 				}
 				return nil
@@ -107,146 +144,338 @@ func (ss *session) Loop(ctx context.Context, commandIn commandIn) error {
 			return fmt.Errorf("commandIn.Read: %w", err)
 		}
 		queryAndTerm := strings.Join(lines, "\n")
-		query, _ := misc.HasTerm(queryAndTerm, ss.Term)
+		query, _ := sqllex.TrimTerm(queryAndTerm, ss.Term, ss.Dialect.Syntax)
 
-		if query == "" {
+		if sqllex.TrimLeft(query, ss.Dialect.Syntax) == "" {
 			continue
 		}
 		if commandIn.ShouldRecordHistory() {
-			ss.history.Add(queryAndTerm)
+			ss.history.Add(redactDataSources(queryAndTerm, ss.Dialect.Syntax))
 		}
-		cmd, arg := misc.CutField(query)
-		switch strings.ToUpper(cmd) {
-		case "REM":
-			// nothing to do
-		case "HOST":
-			process, err := shellcommand.System(arg)
-			if err == nil {
-				process.Wait()
-			}
-		case "SPOOL":
-			fname, _ := misc.CutField(arg)
-			if fname == "" {
-				if ss.spool != nil {
-					fmt.Fprintf(ss.termErr, "Spooling to '%s' now\n", ss.spool.Name())
-				} else {
-					fmt.Fprintln(ss.termErr, "Not Spooling")
+		if cmd, _ := misc.CutField(query); !strings.EqualFold(cmd, "REM") {
+			newQuery, err := ss.substitute(ctx, query, commandIn)
+			if err != nil {
+				fmt.Fprintln(ss.stdErr, err.Error())
+				if err := ss.onError(err, false, commandIn); err != nil {
+					return err
 				}
 				continue
 			}
+			if newQuery != query {
+				printSubstitution(ss, query, newQuery)
+				query = newQuery
+			}
+		}
+		cmd := sqllex.Keyword(query, ss.Dialect.Syntax)
+		stmtCtx, cancel := ss.statementContext(ctx)
+		stmtCtx, cancelTimeout := ss.timeoutContext(stmtCtx, cmd)
+		afterStatement := ss.protectTransaction(query)
+		exit, err := ss.executeReconnecting(stmtCtx, query, commandIn)
+		cancelled := err != nil && stmtCtx.Err() != nil
+		timedOut := cancelled && errors.Is(context.Cause(stmtCtx), context.DeadlineExceeded)
+		cancelTimeout()
+		cancel()
+		afterStatement(cancelled)
+		if exit || ss.exiting {
+			return err
+		}
+		if err != nil {
+			if timedOut {
+				fmt.Fprintf(ss.stdErr, "Cancelled: timeout (%s) exceeded.\n", ss.timeout)
+			} else if cancelled {
+				fmt.Fprintln(ss.stdErr, "Cancelled.")
+			} else {
+				fmt.Fprintln(ss.stdErr, err.Error())
+			}
+			_, arg := misc.CutField(sqllex.TrimLeft(query, ss.Dialect.Syntax))
+			if err := ss.onError(err, !isClientCommand(cmd, arg), commandIn); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// isClientCommand reports whether the statement is a command of SQL-Bless
+// which is processed without sending statements to the server by itself.
+func isClientCommand(cmd, arg string) bool {
+	switch strings.ToUpper(cmd) {
+	case "REM", "HOST", "SPOOL", "PROMPT", "PRO", "VARIABLE", "VAR",
+		"DEFINE", "DEF", "UNDEFINE", "UNDEF", "ACCEPT", "ACC", "HISTORY",
+		"WHENEVER", "CONNECTIONS", "SWITCH", "DISCONNECT", "EXIT", "QUIT",
+		"START":
+		return true
+	case "PRINT":
+		return isBindPrint(arg)
+	case "EXEC", "EXECUTE":
+		return isBindExec(arg)
+	}
+	return strings.HasPrefix(cmd, "@")
+}
+
+// execute runs a statement or a command of SQL-Bless.
+// It reports true when the session should be ended.
+func (ss *session) execute(ctx context.Context, query string, commandIn commandIn) (bool, error) {
+	var err error
+	cmd := sqllex.Keyword(query, ss.Dialect.Syntax)
+	_, arg := misc.CutField(sqllex.TrimLeft(query, ss.Dialect.Syntax))
+	if sqllex.IsAnonymousBlock(query, ss.Dialect.Syntax) {
+		// DECLARE ... BEGIN ... END of PL/SQL
+		cmd = "BEGIN"
+	} else if strings.HasPrefix(cmd, "@@") {
+		// `@@file` of SQL*Plus
+		cmd, arg = "@@", cmd[2:]+" "+arg
+	} else if strings.HasPrefix(cmd, "@") {
+		// `@file` of SQL*Plus
+		cmd, arg = "START", cmd[1:]+" "+arg
+	} else if strings.EqualFold(cmd, "COPY") && !isCopyFrom(arg) {
+		// COPY table ... of PostgreSQL
+		cmd = ""
+	} else if strings.EqualFold(cmd, "ATTACH") && !isAttachCSV(arg) {
+		// ATTACH DATABASE ... of SQLite3
+		cmd = ""
+	} else if strings.EqualFold(cmd, "EXPLAIN") && strings.TrimSpace(arg) != "" && !isExplainOfStatement(arg) {
+		// EXPLAIN ANALYZE ... of PostgreSQL, EXPLAIN table of MySQL
+		cmd = "SELECT"
+	} else if strings.EqualFold(cmd, "PRINT") && !isBindPrint(arg) {
+		// PRINT 'text' of T-SQL
+		cmd = ""
+	} else if (strings.EqualFold(cmd, "EXEC") || strings.EqualFold(cmd, "EXECUTE")) && !isBindExec(arg) {
+		// EXEC procedure of T-SQL and SQL*Plus, EXECUTE statement of PostgreSQL
+		cmd = ""
+	}
+	if ss.scratch != nil && ss.scratch.owns(cmd, arg, query, ss.Dialect.Syntax) {
+		return false, ss.executeInScratch(ctx, query, commandIn)
+	}
+	if err := ss.checkReadOnly(cmd, arg); err != nil {
+		return false, err
+	}
+	switch strings.ToUpper(cmd) {
+	case "REM":
+		// nothing to do
+	case "HOST":
+		process, err := shellcommand.System(arg)
+		if err == nil {
+			process.Wait()
+		}
+	case "SPOOL":
+		fname, _ := misc.CutField(arg)
+		if fname == "" {
 			if ss.spool != nil {
-				ss.spool.Close()
-				fmt.Fprintln(ss.termErr, "Spool closed.")
-				ss.spool = nil
-				ss.stdOut = ss.termOut
-				ss.stdErr = ss.termErr
+				fmt.Fprintf(ss.termErr, "Spooling to '%s' now\n", ss.spool.Name())
+			} else {
+				fmt.Fprintln(ss.termErr, "Not Spooling")
 			}
-			if !strings.EqualFold(fname, "off") {
-				if fd, err := os.OpenFile(fname, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
-					if ss.CrLf {
-						ss.spool = lftocrlf.New(fd)
-					} else {
-						ss.spool = fd
-					}
-					ss.stdOut = io.MultiWriter(ss.termOut, ss.spool)
-					ss.stdErr = io.MultiWriter(ss.termErr, ss.spool)
-					fmt.Fprintf(ss.termErr, "Spool to %s\n", fname)
-					writeSignature(ss.spool)
+			return false, nil
+		}
+		if ss.spool != nil {
+			ss.spool.Close()
+			fmt.Fprintln(ss.termErr, "Spool closed.")
+			ss.spool = nil
+			ss.stdOut = ss.termOut
+			ss.stdErr = ss.termErr
+		}
+		if !strings.EqualFold(fname, "off") {
+			if fd, err := os.OpenFile(fname, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+				if ss.CrLf {
+					ss.spool = lftocrlf.New(fd)
+				} else {
+					ss.spool = fd
 				}
+				ss.stdOut = io.MultiWriter(ss.termOut, ss.spool)
+				ss.stdErr = io.MultiWriter(ss.termErr, ss.spool)
+				fmt.Fprintf(ss.termErr, "Spool to %s\n", fname)
+				writeSignature(ss.spool)
 			}
-		case "EDIT":
-			misc.Echo(ss.spool, query)
-			err = doEdit(ctx, ss, query, commandIn)
+		}
+	case "EDIT":
+		misc.Echo(ss.spool, query)
+		err = doEdit(ctx, ss, query, commandIn)
 
-		case "SELECT":
-			misc.Echo(ss.spool, query)
-			err = doSelect(ctx, ss, query, nil, commandIn)
-		case "ROLLBACK":
-			misc.Echo(ss.spool, query)
-			var rest string
-			arg, rest = misc.CutField(arg)
-			if arg == "" {
+	case "SELECT":
+		misc.Echo(ss.spool, query)
+		var args []any
+		query, args, err = ss.expandBindVariables(ctx, query, commandIn)
+		if err == nil {
+			echoArgs(ss.spool, args)
+			err = doSelect(ctx, ss, query, nil, commandIn, args...)
+		}
+	case "EXPLAIN":
+		misc.Echo(ss.spool, query)
+		err = doExplain(ctx, ss, arg, commandIn)
+	case "ROLLBACK":
+		misc.Echo(ss.spool, query)
+		var rest string
+		arg, rest = misc.CutField(arg)
+		if arg == "" {
+			err = ss.rollback()
+		} else if strings.EqualFold(arg, "TO") {
+			err = doTCL(ctx, ss, query)
+		} else if strings.EqualFold(arg, "TRANSACTION") {
+			if strings.TrimSpace(rest) == "" {
 				err = ss.rollback()
-			} else if strings.EqualFold(arg, "TO") {
-				err = doTCL(ctx, ss, query)
-			} else if strings.EqualFold(arg, "TRANSACTION") {
-				if strings.TrimSpace(rest) == "" {
-					err = ss.rollback()
-				} else {
-					err = doTCL(ctx, ss, query)
-				}
 			} else {
-				err = ErrInvalidRollback
+				err = doTCL(ctx, ss, query)
 			}
+		} else {
+			err = ErrInvalidRollback
+		}
 
-		// Executable but return nothing, safe in transaction
-		case "SAVEPOINT", "SAVE", "RELEASE", "SET":
-			misc.Echo(ss.spool, query)
-			err = doTCL(ctx, ss, query)
+	// Executable but return nothing, safe in transaction
+	case "SAVEPOINT", "SAVE", "RELEASE":
+		misc.Echo(ss.spool, query)
+		if err = doTCL(ctx, ss, query); err == nil && !strings.EqualFold(cmd, "RELEASE") {
+			ss.savepoints++
+		}
+	case "SET":
+		misc.Echo(ss.spool, query)
+		var handled bool
+		if handled, err = ss.setClientOption(arg); !handled {
+			if ss.tx != nil {
+				err = doTCL(ctx, ss, query)
+			} else if err = doSessionSetup(ctx, ss, query); err == nil {
+				ss.recordSetup(cmd, arg, query)
+			}
+		}
 
-		// Updates returning affected row count, safe in transaction
-		case "DELETE", "INSERT", "UPDATE", "MERGE", "REPLACE":
-			misc.Echo(ss.spool, query)
-			isNewTx := (ss.tx == nil)
-			err = ss.beginTx(ctx, ss.stdErr)
+	// Updates returning affected row count, safe in transaction
+	case "DELETE", "INSERT", "UPDATE", "MERGE", "REPLACE":
+		misc.Echo(ss.spool, query)
+		var args []any
+		query, args, err = ss.expandBindVariables(ctx, query, commandIn)
+		if err != nil {
+			break
+		}
+		echoArgs(ss.spool, args)
+		isNewTx := (ss.tx == nil)
+		err = ss.beginTx(ss.stdErr)
+		if err == nil {
+			start := time.Now()
+			var count int64
+			count, err = doDML(ctx, ss.tx, query, args, ss.stdOut)
 			if err == nil {
-				count, err := doDML(ctx, ss.tx, query, nil, ss.stdOut)
-				if (err != nil || count == 0) && isNewTx && ss.tx != nil {
-					ss.tx.Rollback()
-					ss.tx = nil
-				}
+				ss.printElapsed("execute", start)
 			}
-		case "COMMIT":
-			misc.Echo(ss.spool, query)
-			err = ss.commit()
-		case "EXIT", "QUIT":
-			if ss.tx == nil || commandIn.CanCloseInTransaction() {
-				return nil
+			if (err != nil || count == 0) && isNewTx && ss.tx != nil {
+				ss.tx.Rollback()
+				ss.tx = nil
 			}
-			err = ErrTransactionIsNotClosed
-		case "DESC", "\\D":
-			misc.Echo(ss.spool, query)
-			err = doDesc(ctx, ss, arg, commandIn)
-		case "HISTORY":
+		}
+	case "EXPORT":
+		misc.Echo(ss.spool, query)
+		err = doExport(ctx, ss, arg, commandIn)
+	case "IMPORT":
+		misc.Echo(ss.spool, query)
+		err = doImport(ctx, ss, arg)
+	case "DUMP":
+		misc.Echo(ss.spool, query)
+		err = doDump(ctx, ss, arg)
+	case "COPY":
+		misc.Echo(ss.spool, redactDataSources(query, ss.Dialect.Syntax))
+		err = doCopy(ctx, ss, arg)
+	case "CONNECT":
+		misc.Echo(ss.spool, redactDataSources(query, ss.Dialect.Syntax))
+		err = doConnect(ctx, ss, arg)
+	case "SWITCH":
+		misc.Echo(ss.spool, query)
+		err = doSwitch(ss, arg)
+	case "DISCONNECT":
+		misc.Echo(ss.spool, query)
+		err = doDisconnect(ss, arg)
+	case "CONNECTIONS":
+		misc.Echo(ss.spool, query)
+		doConnections(ss)
+	case "ATTACH":
+		misc.Echo(ss.spool, query)
+		err = doAttach(ctx, ss, arg)
+	case "STASH":
+		misc.Echo(ss.spool, query)
+		err = doStash(ctx, ss, arg, commandIn)
+	case "SCRATCH":
+		// The statement is recorded into the spool by the scratch session.
+		err = doScratch(ctx, ss, arg, commandIn)
+	case "COMMIT":
+		misc.Echo(ss.spool, query)
+		err = ss.commit()
+	case "EXIT", "QUIT":
+		err = doExit(ss, arg, commandIn)
+		return ss.exiting, err
+	case "WHENEVER":
+		misc.Echo(ss.spool, query)
+		err = doWhenever(ss, arg)
+	case "PROMPT", "PRO":
+		doPrompt(ss, arg)
+	case "VARIABLE", "VAR":
+		misc.Echo(ss.spool, query)
+		err = doVariable(ss, arg)
+	case "PRINT":
+		misc.Echo(ss.spool, query)
+		err = doPrint(ss, arg)
+	case "EXEC", "EXECUTE":
+		misc.Echo(ss.spool, query)
+		err = doExec(ss, arg)
+	case "DEFINE", "DEF":
+		misc.Echo(ss.spool, query)
+		err = doDefine(ss, arg)
+	case "UNDEFINE", "UNDEF":
+		misc.Echo(ss.spool, query)
+		err = doUndefine(ss, arg)
+	case "ACCEPT", "ACC":
+		misc.Echo(ss.spool, query)
+		err = doAccept(ctx, ss, arg, commandIn)
+	case "DESC", "\\D":
+		misc.Echo(ss.spool, query)
+		err = doDesc(ctx, ss, arg, commandIn)
+	case "HISTORY":
+		misc.Echo(ss.spool, query)
+		csvw := csv.NewWriter(ss.stdOut)
+		for i, end := 0, ss.history.Len(); i < end; i++ {
+			text, stamp := ss.history.TextAndStamp(i)
+			csvw.Write([]string{
+				strconv.Itoa(i),
+				stamp.Local().Format(time.DateTime),
+				text})
+		}
+		csvw.Flush()
+	case "START":
+		err = doStart(ctx, ss, arg, false)
+	case "@@":
+		err = doStart(ctx, ss, arg, true)
+	case "BEGIN":
+		if sqllex.IsAnonymousBlock(query, ss.Dialect.Syntax) {
 			misc.Echo(ss.spool, query)
-			csvw := csv.NewWriter(ss.stdOut)
-			for i, end := 0, ss.history.Len(); i < end; i++ {
-				text, stamp := ss.history.TextAndStamp(i)
-				csvw.Write([]string{
-					strconv.Itoa(i),
-					stamp.Local().Format(time.DateTime),
-					text})
-			}
-			csvw.Flush()
-		case "START":
-			fname, _ := misc.CutField(arg)
-			err = ss.Start(ctx, fname)
-		case "BEGIN":
+			err = doBlock(ctx, ss, query, commandIn)
+		} else {
 			err = ErrBeginIsNotSupported
-		default:
-			misc.Echo(ss.spool, query)
-			if q := ss.Dialect.IsQuerySQL; q != nil && q(query) {
-				err = doSelect(ctx, ss, query, nil, commandIn)
-			} else {
-				if ss.tx == nil {
-					_, err = ss.conn.ExecContext(ctx, query)
-				} else if f := ss.Dialect.IsTransactionSafe; f != nil && f(query) {
-					_, err = ss.tx.ExecContext(ctx, query)
-				} else {
-					err = ErrTransactionIsNotClosed
-				}
-				if err == nil {
-					fmt.Fprintln(ss.stdErr, "Ok")
-				}
-			}
 		}
+	default:
+		misc.Echo(ss.spool, query)
+		var args []any
+		query, args, err = ss.expandBindVariables(ctx, query, commandIn)
 		if err != nil {
-			fmt.Fprintln(ss.stdErr, err.Error())
-			if commandIn.OnErrorAbort() {
-				return err
+			break
+		}
+		echoArgs(ss.spool, args)
+		if q := ss.Dialect.IsQuerySQL; q != nil && q(query) {
+			err = doSelect(ctx, ss, query, nil, commandIn, args...)
+		} else {
+			start := time.Now()
+			if ss.tx == nil {
+				_, err = ss.conn.ExecContext(ctx, query, args...)
+			} else if f := ss.Dialect.IsTransactionSafe; f != nil && f(query) {
+				_, err = ss.tx.ExecContext(ctx, query, args...)
+			} else {
+				err = ErrTransactionIsNotClosed
+			}
+			if err == nil {
+				fmt.Fprintln(ss.stdErr, "Ok")
+				ss.printElapsed("execute", start)
+				if len(args) <= 0 {
+					ss.recordSetup(cmd, arg, query)
+				}
 			}
 		}
 	}
+	return false, err
 }
 
 func (cfg *Config) openSpool() lftocrlf.WriteNameCloser {
@@ -277,40 +506,99 @@ func (cfg *Config) Run(driver, dataSourceName string, dbDialect *dialect.Entry)
 	termOut := colorable.NewColorableStdout()
 	termErr := colorable.NewColorableStderr()
 
+	timeout, err := parseTimeout(cfg.Timeout)
+	if err != nil {
+		return fmt.Errorf("-timeout: %w", err)
+	}
+	keepAlive, err := parseTimeout(cfg.KeepAlive)
+	if err != nil {
+		return fmt.Errorf("-keepalive: %w", err)
+	}
+	if len(cfg.Commands) > 0 && cfg.Script != "" {
+		return ErrCommandWithScript
+	}
+	outFormat, err := cfg.outputFormat(stdoutIsTerminal())
+	if err != nil {
+		return fmt.Errorf("-format: %w", err)
+	}
+	var resultOut io.Writer
+	if outFormat != "" {
+		// The standard output is only for the results.
+		resultOut = os.Stdout
+		termOut = termErr
+	}
+
 	db, err := sql.Open(driver, dataSourceName)
 	if err != nil {
-		return fmt.Errorf("sql.Open: %[1]w (%[1]T)", err)
+		return fmt.Errorf("sql.Open: %w (%T)", dialect.RedactError(dataSourceName, err), err)
 	}
 	defer db.Close()
 
 	if err = db.Ping(); err != nil {
-		return fmt.Errorf("db.Ping: %w", err)
+		return &ExitError{Code: ExitConnectionError, Err: fmt.Errorf("db.Ping: %w", dialect.RedactError(dataSourceName, err))}
 	}
 	conn, err := db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("db.Conn: %w", err)
+		return &ExitError{Code: ExitConnectionError, Err: fmt.Errorf("db.Conn: %w", dialect.RedactError(dataSourceName, err))}
 	}
 	defer conn.Close()
 
 	ss := &session{
-		Config:  cfg,
-		Dialect: dbDialect,
-		conn:    conn,
-		history: &history.History{},
-		stdOut:  termOut,
-		termOut: termOut,
-		stdErr:  termErr,
-		termErr: termErr,
-		spool:   cfg.openSpool(),
+		Config:    cfg,
+		Dialect:   dbDialect,
+		conn:      conn,
+		history:   &history.History{},
+		stdOut:    termOut,
+		termOut:   termOut,
+		stdErr:    termErr,
+		termErr:   termErr,
+		spool:     cfg.openSpool(),
+		outFormat: outFormat,
+		resultOut: resultOut,
+		keepAlive: keepAlive,
 	}
+	color, err := colorSequence(cfg.Color)
+	if err != nil {
+		return fmt.Errorf("-color: %w", err)
+	}
+	ss.current = &connection{name: mainConnection, driver: driver, readOnly: cfg.ReadOnly, color: color, db: db}
+	ss.connections = []*connection{ss.current}
 	defer ss.Close()
 
+	if cfg.ReadOnly {
+		if err := setReadOnly(ctx, conn, dbDialect); err != nil {
+			return err
+		}
+	}
+
+	if err := ss.setTimeout(ctx, timeout); err != nil {
+		return err
+	}
+
+	for _, fname := range cfg.Attach {
+		spec := &attachSpec{file: fname, name: attachNameOf(fname), format: fileFormatOf(fname)}
+		if spec.format != "tsv" {
+			spec.format = "csv"
+		}
+		count, err := ss.attachCSV(ctx, spec)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(ss.stdErr, "%d row(s) attached as %s\n", count, spec.name)
+	}
+
+	stopInterrupter := ss.startInterrupter()
+	defer stopInterrupter()
+
+	if len(cfg.Commands) > 0 {
+		return ss.exitStatus(ss.RunCommands(ctx, cfg.Commands))
+	}
 	if cfg.Script != "" {
-		return ss.Start(ctx, cfg.Script)
+		return ss.exitStatus(ss.Start(ctx, cfg.Script))
 	}
 
 	if !isatty.IsTerminal(os.Stdin.Fd()) && !ss.automatic() {
-		return ss.StartFromStdin(ctx)
+		return ss.exitStatus(ss.StartFromStdin(ctx))
 	}
-	return ss.Loop(ctx, ss.newInteractiveIn())
+	return ss.exitStatus(ss.Loop(ctx, ss.newInteractiveIn()))
 }
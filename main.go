@@ -25,6 +25,16 @@ type Config struct {
 	Script         string `flag:"f,script file"`
 	SpoolFilename  string `flag:"spool,Spool filename"`
 	ReverseVideo   bool   `flag:"rv,Enable reverse-video display (invert foreground and background colors)"`
+	Timing         bool   `flag:"timing,Report the elapsed time of each statement"`
+	Timeout        string `flag:"timeout,Cancel statements running longer than this (e.g. 30s, 5m)"`
+	Format         string `flag:"format,Write results to stdout as csv|tsv|json|jsonl|table instead of the viewer"`
+	Commit         bool   `flag:"commit,Commit the transaction at the end of -c (default: rollback)"`
+	ReadOnly       bool   `flag:"readonly,Refuse statements changing the database"`
+	Color          string `flag:"color,Color of the prompt: red, green, yellow, blue, magenta or cyan"`
+	PasswordFile   string `flag:"password-file,Read the password for the placeholder of the DSN from the file"`
+	KeepAlive      string `flag:"keepalive,Ping the server at this interval while waiting for input (e.g. 5m)"`
+	Commands       []string
+	Attach         []string
 }
 
 func (cfg *Config) comma() byte {
@@ -38,10 +48,13 @@ func (cfg *Config) comma() byte {
 	return ','
 }
 
+// defaultNull is the representation of NULL without -null.
+const defaultNull = "\u2400"
+
 func New() *Config {
 	return &Config{
 		FieldSeperator: ",",
-		Null:           "\u2400",
+		Null:           defaultNull,
 		Term:           ";",
 		SpoolFilename:  os.DevNull,
 	}
@@ -49,6 +62,7 @@ func New() *Config {
 
 func (cfg *Config) Bind(fs *flag.FlagSet) *Config {
 	struct2flag.Bind(fs, cfg)
+	fs.Var((*commandList)(&cfg.Commands), "c", "Run the statement and exit (repeatable)")
 	return cfg
 }
 
@@ -62,6 +76,7 @@ func writeSignature(w io.Writer) {
 func usage() {
 	w := flag.CommandLine.Output()
 	fmt.Fprintf(w, "Usage: %s {-options} [DRIVERNAME] DATASOURCENAME\n", os.Args[0])
+	fmt.Fprintf(w, "   or: %s {-options} @PROFILENAME\n", os.Args[0])
 	flag.PrintDefaults()
 	fmt.Fprintln(w, "Example:")
 	dialect.Each(
@@ -73,23 +88,44 @@ func usage() {
 }
 
 func Run() error {
-	writeSignature(os.Stdout)
-
 	cfg := New().Bind(flag.CommandLine)
+	profileName := flag.String("profile", "", "Connect with the profile of profiles.json (same as @name)")
 	flag.Usage = usage
 	flag.Parse()
 	args := flag.Args()
+	if *profileName != "" {
+		args = append([]string{"@" + *profileName}, args...)
+	}
+	var dbArgs []string
+	var p *profile
+	var err error
+	if len(args) >= 1 {
+		dbArgs, p, err = profileArgs(args)
+		if p != nil && err == nil {
+			err = p.override(flag.CommandLine)
+		}
+	}
+
+	if format, _ := cfg.outputFormat(stdoutIsTerminal()); format != "" {
+		writeSignature(os.Stderr)
+	} else {
+		writeSignature(os.Stdout)
+	}
 
 	if len(args) < 1 {
 		flag.Usage()
 		return nil
 	}
-	d, err := dialect.ReadDBInfoFromArgs(args)
+	if err != nil {
+		return err
+	}
+	dialect.PasswordFile = cfg.PasswordFile
+	d, err := readDBInfoFromArgs(dbArgs)
 	if err != nil {
 		return fmt.Errorf("dialect.ReadDBInfoFromArgs: %w", err)
 	}
 	if cfg.Debug {
-		fmt.Fprintln(os.Stderr, d.DataSource)
+		fmt.Fprintln(os.Stderr, d.RedactedDataSource())
 	}
 	return cfg.Run(d.Driver, d.DataSource, d.Dialect)
 }
@@ -2,6 +2,7 @@ package sqlbless
 
 import (
 	"bufio"
+	"encoding/csv"
 	"os"
 	"path/filepath"
 	"strings"
@@ -80,3 +81,60 @@ func TestConfigRun(t *testing.T) {
 	}
 	t.Fatalf("%s: not found", "2015-06-07 20:21:22")
 }
+
+// runSQLiteScript runs script on the in-memory SQLite3 database and
+// returns the records written to the spool file named `{{SPOOL}}` in script.
+func runSQLiteScript(t *testing.T, script string) [][]string {
+	t.Helper()
+	records, err := runSQLiteScriptError(t, script)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return records
+}
+
+// runSQLiteScriptError is similar with runSQLiteScript, but it returns
+// the error of the script with the spooled records instead of failing.
+func runSQLiteScriptError(t *testing.T, script string) ([][]string, error) {
+	t.Helper()
+	restoreColor := disableColor()
+	defer restoreColor()
+
+	tmpDir := t.TempDir()
+	testLst := filepath.Join(tmpDir, "output.lst")
+	scriptPath := filepath.Join(tmpDir, "script.sql")
+	script = strings.ReplaceAll(script, "{{SPOOL}}", testLst)
+	if err := os.WriteFile(scriptPath, []byte(script), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	cfg := New()
+	d, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cfg.Script = scriptPath
+	runErr := cfg.Run(d.Driver, d.DataSource, d.Dialect)
+	fd, err := os.Open(testLst)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer fd.Close()
+
+	csvr := csv.NewReader(fd)
+	csvr.Comment = '#'
+	csvr.FieldsPerRecord = -1
+	records, err := csvr.ReadAll()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return records, runErr
+}
+
+func sqliteDialectForTest(t *testing.T) *dialect.Entry {
+	t.Helper()
+	d, ok := dialect.Find("sqlite3")
+	if !ok {
+		t.Fatal("sqlite3 dialect not found")
+	}
+	return d
+}
@@ -0,0 +1,56 @@
+package sqlbless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrCommandWithScript = errors.New("-c and -f can not be used together")
+
+// commandList is the value of the repeatable option -c.
+type commandList []string
+
+func (c *commandList) String() string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(*c, " ")
+}
+
+func (c *commandList) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+// RunCommands runs the statements given by -c in order as a script and
+// ends the transaction with COMMIT when -commit is given, otherwise with
+// ROLLBACK.
+func (ss *session) RunCommands(ctx context.Context, commands []string) error {
+	var pending strings.Builder
+	for _, c := range commands {
+		pending.WriteString(c)
+		pending.WriteString("\n" + ss.Term + "\n")
+	}
+	script := &scriptIn{
+		pending: pending.String(),
+		echo:    func(string) {},
+		term:    ss.Term,
+		syntax:  ss.Dialect.Syntax,
+	}
+	if err := ss.Loop(ctx, script); err != nil {
+		return err
+	}
+	if len(ss.openTransactions()) <= 0 {
+		return nil
+	}
+	if ss.Commit {
+		return ss.forEachConnection(func() error { return ss.closeTransaction("COMMIT") })
+	}
+	if err := ss.forEachConnection(func() error { return ss.closeTransaction("ROLLBACK") }); err != nil {
+		return err
+	}
+	fmt.Fprintln(ss.stdErr, "The transaction was rolled back. Use -commit to commit it.")
+	return nil
+}
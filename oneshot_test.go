@@ -0,0 +1,46 @@
+package sqlbless
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func TestRunCommands(t *testing.T) {
+	restoreColor := disableColor()
+	defer restoreColor()
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	d, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", dbPath})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	run := func(commit bool, commands ...string) string {
+		t.Helper()
+		cfg := New()
+		cfg.Commands = commands
+		cfg.Commit = commit
+		cfg.Format = "csv"
+		output, err := captureStdout(t, func() error {
+			return cfg.Run(d.Driver, d.DataSource, d.Dialect)
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return string(output)
+	}
+	run(true, "CREATE TABLE T ( ID INTEGER )", "INSERT INTO T VALUES (1); INSERT INTO T VALUES (2)")
+	run(false, "INSERT INTO T VALUES (3)")
+	if output := run(false, "SELECT COUNT(*) AS N FROM T;"); output != "N\n2\n" {
+		t.Fatalf("expect %q, but %q", "N\n2\n", output)
+	}
+
+	cfg := New()
+	cfg.Commands = []string{"SELECT 1"}
+	cfg.Script = "main.sql"
+	if err := cfg.Run(d.Driver, d.DataSource, d.Dialect); !errors.Is(err, ErrCommandWithScript) {
+		t.Fatalf("expect %v, but %v", ErrCommandWithScript, err)
+	}
+}
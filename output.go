@@ -0,0 +1,87 @@
+package sqlbless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+
+	"github.com/hymkor/sqlbless/rowstocsv"
+
+	"github.com/hymkor/sqlbless/internal/sqllex"
+)
+
+var ErrUnknownOutputFormat = errors.New("unknown output format: expected csv, tsv, json, jsonl or table")
+
+// stdoutIsTerminal reports whether the standard output is a terminal.
+// When it is not, the results are written as text instead of the viewer.
+var stdoutIsTerminal = func() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// outputFormat returns the format of rowstocsv.NewEncoder to write the
+// results to the standard output, or an empty string to use the viewer.
+// Without -format, CSV (or TSV with -tsv) is used when the standard output
+// is not a terminal, and the aligned table for -c on a terminal.
+func (cfg *Config) outputFormat(isTerminal bool) (string, error) {
+	switch format := strings.ToLower(cfg.Format); format {
+	case "":
+		if !isTerminal {
+			return "csv", nil
+		}
+		if len(cfg.Commands) > 0 {
+			return "text", nil
+		}
+		return "", nil
+	case "csv", "tsv", "json", "jsonl":
+		return format, nil
+	case "table":
+		return "text", nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownOutputFormat, cfg.Format)
+	}
+}
+
+// tableOf returns the table name used by INSERT statements for the query.
+func (ss *session) tableOf(query string) string {
+	if table := sqllex.FirstTable(query, ss.Dialect.Syntax); table != "" {
+		return table
+	}
+	return "T"
+}
+
+// printRows writes the rows to the standard output in the format of
+// -format instead of the viewer. The spool receives them in the format
+// of SET FORMAT as the viewer does. NULL is written as an empty field of
+// CSV and TSV to pipes unless -null is given.
+func (ss *session) printRows(ctx context.Context, title string, rows rowstocsv.Source) error {
+	table := ss.tableOf(title)
+	if ss.spool != nil {
+		format := ss.format
+		if format == "" {
+			format = "csv"
+		}
+		enc, err := rowstocsv.NewEncoder(format, ss.spool, ss.encoderConfig(table))
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		rows = rowstocsv.Tee(rows, enc)
+	}
+	cfg := ss.encoderConfig(table)
+	cfg.UseCRLF = ss.CrLf
+	if (ss.outFormat == "csv" || ss.outFormat == "tsv") && cfg.Null == defaultNull && !stdoutIsTerminal() {
+		cfg.Null = ""
+	}
+	enc, err := rowstocsv.NewEncoder(ss.outFormat, ss.resultOut, cfg)
+	if err != nil {
+		rows.Close()
+		return err
+	}
+	err = cfg.Encode(ctx, rows, enc)
+	return errors.Join(err, rows.Close())
+}
@@ -0,0 +1,122 @@
+package sqlbless
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func TestOutputFormat(t *testing.T) {
+	for _, c := range []struct {
+		format     string
+		isTerminal bool
+		expect     string
+		err        error
+	}{
+		{format: "", isTerminal: true, expect: ""},
+		{format: "", isTerminal: false, expect: "csv"},
+		{format: "JSON", isTerminal: true, expect: "json"},
+		{format: "table", isTerminal: false, expect: "text"},
+		{format: "xlsx", err: ErrUnknownOutputFormat},
+	} {
+		cfg := New()
+		cfg.Format = c.format
+		result, err := cfg.outputFormat(c.isTerminal)
+		if c.err != nil {
+			if !errors.Is(err, c.err) {
+				t.Fatalf("%s: expect %v, but %v", c.format, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", c.format, err.Error())
+		}
+		if result != c.expect {
+			t.Fatalf("%s: expect %q, but %q", c.format, c.expect, result)
+		}
+	}
+}
+
+func TestFormatOption(t *testing.T) {
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CREATE TABLE T ( ID INTEGER, NAME CHAR VARYING(20) );
+			INSERT INTO T VALUES (1, 'a');
+			INSERT INTO T VALUES (2, NULL);
+			SELECT * FROM T ORDER BY ID;
+			ROLLBACK;`,
+	})
+	restoreColor := disableColor()
+	defer restoreColor()
+
+	d, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cfg := New()
+	cfg.Script = filepath.Join(dir, "main.sql")
+	cfg.Format = "jsonl"
+	cfg.SpoolFilename = spool
+	var output []byte
+	output, err = captureStdout(t, func() error {
+		return cfg.Run(d.Driver, d.DataSource, d.Dialect)
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := `{"ID":1,"NAME":"a"}` + "\n" + `{"ID":2,"NAME":null}` + "\n"
+	if string(output) != expect {
+		t.Fatalf("expect %q, but %q", expect, string(output))
+	}
+	output, err = os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if expect := "\nID,NAME\n1,a\n2,␀\n"; !strings.Contains(string(output), expect) {
+		t.Fatalf("%q is not found in %q", expect, string(output))
+	}
+
+	// NULL is an empty field of CSV in pipes unless -null is given.
+	for null, expect := range map[string]string{
+		defaultNull: "ID,NAME\n1,a\n2,\n",
+		"NULL":      "ID,NAME\n1,a\n2,NULL\n",
+	} {
+		cfg.Format = "csv"
+		cfg.Null = null
+		output, err = captureStdout(t, func() error {
+			return cfg.Run(d.Driver, d.DataSource, d.Dialect)
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if string(output) != expect {
+			t.Fatalf("expect %q, but %q", expect, string(output))
+		}
+	}
+}
+
+// captureStdout returns what f writes to the standard output.
+func captureStdout(t *testing.T, f func() error) ([]byte, error) {
+	t.Helper()
+	fd, err := os.Create(filepath.Join(t.TempDir(), "stdout.txt"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	saveStdout := os.Stdout
+	os.Stdout = fd
+	err = f()
+	os.Stdout = saveStdout
+	fd.Close()
+
+	output, err1 := os.ReadFile(fd.Name())
+	if err1 != nil {
+		t.Fatal(err1.Error())
+	}
+	return output, err
+}
@@ -0,0 +1,282 @@
+package sqlbless
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hymkor/sqlbless/dialect"
+	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/internal/sqllex"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrProfileNoDSN    = errors.New("the profile has no dsn")
+	ErrReadOnly        = errors.New("the connection is read-only")
+	ErrUnknownColor    = errors.New("unknown color: expected red, green, yellow, blue, magenta or cyan")
+)
+
+// profile is a named connection of the profiles file. The keys other than
+// driver and dsn are the names of the options and their values.
+type profile struct {
+	Name    string
+	Driver  string
+	DSN     string
+	Options map[string]string
+}
+
+// profilesPath returns the path of the profiles file such as
+// ~/.config/sqlbless/profiles.json.
+func profilesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sqlbless", "profiles.json"), nil
+}
+
+// parseProfiles reads the profiles file in the form of
+// {"name": {"driver": "...", "dsn": "...", "option": value, ...}, ...}.
+func parseProfiles(data []byte) (map[string]*profile, error) {
+	var raw map[string]map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	profiles := make(map[string]*profile, len(raw))
+	for name, values := range raw {
+		p := &profile{Name: name, Options: map[string]string{}}
+		for key, value := range values {
+			text := fmt.Sprint(value)
+			switch strings.ToLower(key) {
+			case "driver":
+				p.Driver = text
+			case "dsn":
+				p.DSN = text
+			default:
+				p.Options[strings.ToLower(key)] = text
+			}
+		}
+		profiles[strings.ToUpper(name)] = p
+	}
+	return profiles, nil
+}
+
+func loadProfile(name string) (*profile, error) {
+	path, err := profilesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := parseProfiles(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p, ok := profiles[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s in %s", ErrProfileNotFound, name, path)
+	}
+	if p.DSN == "" {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNoDSN, name)
+	}
+	return p, nil
+}
+
+// args returns the arguments for dialect.ReadDBInfoFromArgs.
+func (p *profile) args() []string {
+	if p.Driver == "" {
+		return []string{p.DSN}
+	}
+	return []string{p.Driver, p.DSN}
+}
+
+// override sets the options of the profile to the flags not given on the
+// command line, so that the command line wins.
+func (p *profile) override(fs *flag.FlagSet) error {
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	keys := make([]string, 0, len(p.Options))
+	for key := range p.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if given[key] {
+			continue
+		}
+		if err := fs.Set(key, p.Options[key]); err != nil {
+			return fmt.Errorf("profile %s: %s: %w", p.Name, key, err)
+		}
+	}
+	return nil
+}
+
+func (p *profile) readOnly() (bool, error) {
+	value, ok := p.Options["readonly"]
+	if !ok {
+		return false, nil
+	}
+	readOnly, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("profile %s: readonly: %w", p.Name, err)
+	}
+	return readOnly, nil
+}
+
+// profileArgs replaces `@name` given as the only argument with the
+// driver and the dsn of the profile. The profile is nil without it.
+func profileArgs(args []string) ([]string, *profile, error) {
+	if len(args) != 1 || !strings.HasPrefix(args[0], "@") {
+		return args, nil, nil
+	}
+	p, err := loadProfile(args[0][1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.args(), p, nil
+}
+
+// readDBInfo is dialect.ReadDBInfoFromArgs accepting `@name` of the
+// profile as the only argument.
+func readDBInfo(args []string) (*dialect.DBInfo, *profile, error) {
+	args, p, err := profileArgs(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := readDBInfoFromArgs(args)
+	return d, p, err
+}
+
+// readDBInfoFromArgs is dialect.ReadDBInfoFromArgs whose errors have the
+// passwords of the arguments redacted.
+func readDBInfoFromArgs(args []string) (*dialect.DBInfo, error) {
+	d, err := dialect.ReadDBInfoFromArgs(args)
+	if err != nil {
+		// The errors of parsing URLs have the DSN.
+		for _, arg := range args {
+			err = dialect.RedactError(arg, err)
+		}
+	}
+	return d, err
+}
+
+var colorSequences = map[string]string{
+	"red":     "\x1B[31;49;1m",
+	"green":   "\x1B[32;49;1m",
+	"yellow":  "\x1B[33;49;1m",
+	"blue":    "\x1B[34;49;1m",
+	"magenta": "\x1B[35;49;1m",
+	"cyan":    "\x1B[36;49;1m",
+}
+
+func colorSequence(color string) (string, error) {
+	if color == "" {
+		return "", nil
+	}
+	if seq, ok := colorSequences[strings.ToLower(color)]; ok {
+		return seq, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownColor, color)
+}
+
+// readOnlyCommands are the statements and the commands of SQL-Bless
+// allowed on read-only connections besides the ones of the client.
+// WITH and STASH are allowed only for queries by isQuery.
+var readOnlyCommands = map[string]struct{}{
+	"ATTACH":   o,
+	"COMMIT":   o,
+	"CONNECT":  o,
+	"DESC":     o,
+	"DUMP":     o,
+	"EXPLAIN":  o,
+	"EXPORT":   o,
+	"ROLLBACK": o,
+	"SCRATCH":  o,
+	"SELECT":   o,
+	"SHOW":     o,
+	"TABLE":    o,
+	"VALUES":   o,
+	"\\D":      o,
+}
+
+// isQuery reports whether the statement is a query. The expressions of
+// WITH are also checked, since they may update tables on PostgreSQL.
+func isQuery(query string, syntax dialect.Syntax) bool {
+	for _, keyword := range sqllex.StatementKeywords(query, syntax) {
+		switch strings.ToUpper(keyword) {
+		case "SELECT", "VALUES", "TABLE":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// checkReadOnly refuses the statements other than queries and the commands
+// of the client on the read-only connection. SET is allowed only for the
+// options of the client, so that the session is not made writable.
+func (ss *session) checkReadOnly(cmd, arg string) error {
+	if ss.current == nil || !ss.current.readOnly {
+		return nil
+	}
+	name := strings.ToUpper(cmd)
+	if _, ok := readOnlyCommands[name]; ok {
+		return nil
+	}
+	switch name {
+	case "WITH":
+		if isQuery(cmd+" "+arg, ss.Dialect.Syntax) {
+			return nil
+		}
+	case "STASH":
+		if _, query := misc.CutField(arg); isQuery(query, ss.Dialect.Syntax) {
+			return nil
+		}
+	}
+	if isClientCommand(cmd, arg) || (name == "SET" && isClientOption(arg)) {
+		return nil
+	}
+	if name == "" {
+		return ErrReadOnly
+	}
+	return fmt.Errorf("%w: %s", ErrReadOnly, name)
+}
+
+// setReadOnly makes the session of the connection read-only on the server
+// with SQLForReadOnly of the dialect.
+func setReadOnly(ctx context.Context, conn *sql.Conn, d *dialect.Entry) error {
+	query := d.SQLForReadOnly
+	if query == "" {
+		return nil
+	}
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%s: %w", query, err)
+	}
+	return nil
+}
+
+// setReadOnlyTransaction makes the transaction begun on the read-only
+// connection read-only with SQLForReadOnlyTransaction of the dialect.
+func (ss *session) setReadOnlyTransaction(ctx context.Context) error {
+	query := ss.Dialect.SQLForReadOnlyTransaction
+	if ss.current == nil || !ss.current.readOnly || query == "" {
+		return nil
+	}
+	if _, err := ss.tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%s: %w", query, err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package sqlbless
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+)
+
+var ErrTransactionLost = errors.New("the connection was lost, so the transaction was rolled back by the server")
+
+// isSessionSetup reports whether the statement changes the state of the
+// session such as the current schema, which a new connection lacks.
+func isSessionSetup(cmd, arg string) bool {
+	switch strings.ToUpper(cmd) {
+	case "SET", "USE":
+		return true
+	case "ALTER":
+		word, _ := misc.CutField(arg)
+		return strings.EqualFold(word, "SESSION")
+	}
+	return false
+}
+
+// recordSetup remembers the statement to replay it on a new connection.
+// The statements in a transaction such as SET LOCAL are not remembered,
+// since they end with the transaction.
+func (ss *session) recordSetup(cmd, arg, query string) {
+	if ss.tx == nil && isSessionSetup(cmd, arg) {
+		ss.setup = append(ss.setup, query)
+	}
+}
+
+// isRetryable reports whether err tells that the statement was not sent
+// to the server, so it can be executed again on a new connection.
+func isRetryable(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone)
+}
+
+// reconnect replaces the lost connection with a new one of the pool and
+// replays the setup of the session. The transaction of the lost
+// connection is reported and recorded into the spool.
+func (ss *session) reconnect(ctx context.Context) error {
+	c := ss.current
+	if ss.tx != nil {
+		ss.tx.Rollback()
+		ss.tx = nil
+		fmt.Fprintln(ss.stdErr, ErrTransactionLost.Error())
+	}
+	// Returning driver.ErrBadConn discards the connection from the pool.
+	ss.conn.Raw(func(any) error { return driver.ErrBadConn })
+	ss.conn.Close()
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("db.Conn: %w", err)
+	}
+	if c.readOnly {
+		if err := setReadOnly(ctx, conn, ss.Dialect); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	ss.conn = conn
+	c.complete = nil
+	for _, query := range ss.setup {
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			fmt.Fprintf(ss.stdErr, "%s: %s\n", query, err.Error())
+		}
+	}
+	if err := ss.applyServerTimeout(ctx); err != nil {
+		fmt.Fprintln(ss.stdErr, err.Error())
+	}
+	fmt.Fprintf(ss.stdErr, "Reconnected to %s as %s\n", c.driver, c.name)
+	return nil
+}
+
+// executeReconnecting executes the statement and reconnects when the
+// connection was lost. The statement is executed again on the new
+// connection when it had not been sent outside of a transaction.
+func (ss *session) executeReconnecting(ctx context.Context, query string, commandIn commandIn) (bool, error) {
+	inTx := ss.tx != nil
+	exit, err := ss.execute(ctx, query, commandIn)
+	if err == nil || exit || ss.exiting || ctx.Err() != nil || !isConnectionError(err) {
+		return exit, err
+	}
+	if ss.current == nil || ss.current.db == nil {
+		return exit, err
+	}
+	if rerr := ss.reconnect(ctx); rerr != nil {
+		return false, errors.Join(err, rerr)
+	}
+	if inTx || !isRetryable(err) {
+		return false, err
+	}
+	return ss.execute(ctx, query, commandIn)
+}
+
+// startKeepAlive pings the connections at the interval of -keepalive
+// while the session waits for input, so that idle connections are not
+// closed by firewalls. The lost ones are replaced at the next statement.
+// It returns the function to stop it, which does not wait for the ping in
+// flight.
+func (ss *session) startKeepAlive() func() {
+	if ss.keepAlive <= 0 {
+		return func() {}
+	}
+	var conns []*sql.Conn
+	ss.forEachConnection(func() error {
+		conns = append(conns, ss.conn)
+		return nil
+	})
+	interval := ss.keepAlive
+	quit := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+				// Not cancelled by quit, since some drivers close the
+				// connection on cancellation. The connections not
+				// answering in time are lost anyway.
+				for _, conn := range conns {
+					ctx, cancel := context.WithTimeout(context.Background(), interval)
+					conn.PingContext(ctx)
+					cancel()
+				}
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+	}
+}
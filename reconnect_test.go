@@ -0,0 +1,192 @@
+package sqlbless
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+	"github.com/hymkor/sqlbless/internal/history"
+)
+
+// flakyDriver wraps the driver of SQLite3 and fails the next operation
+// with driver.ErrBadConn when breakNext is set. It accepts SET statements,
+// which SQLite3 lacks, and records them into sets.
+type flakyDriver struct {
+	sqlite    driver.Driver
+	dsn       string
+	breakNext bool
+	sets      []string
+}
+
+func (d *flakyDriver) Connect(context.Context) (driver.Conn, error) {
+	return d.Open(d.dsn)
+}
+
+func (d *flakyDriver) Driver() driver.Driver {
+	return d
+}
+
+type flakyConn struct {
+	driver.Conn
+	d *flakyDriver
+}
+
+func (d *flakyDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.sqlite.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &flakyConn{Conn: c, d: d}, nil
+}
+
+func (c *flakyConn) Prepare(query string) (driver.Stmt, error) {
+	if c.d.breakNext {
+		c.d.breakNext = false
+		return nil, driver.ErrBadConn
+	}
+	if strings.HasPrefix(strings.ToUpper(query), "SET ") {
+		c.d.sets = append(c.d.sets, query)
+		return setStmt{}, nil
+	}
+	return c.Conn.Prepare(query)
+}
+
+// setStmt is the statement of SET which does nothing.
+type setStmt struct{}
+
+func (setStmt) Close() error  { return nil }
+func (setStmt) NumInput() int { return -1 }
+
+func (setStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (setStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *flakyConn) Begin() (driver.Tx, error) {
+	if c.d.breakNext {
+		c.d.breakNext = false
+		return nil, driver.ErrBadConn
+	}
+	return c.Conn.Begin()
+}
+
+func TestReconnect(t *testing.T) {
+	d, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sqlite, err := sql.Open(d.Driver, d.DataSource)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer sqlite.Close()
+	flaky := &flakyDriver{sqlite: sqlite.Driver(), dsn: d.DataSource}
+	db := sql.OpenDB(flaky)
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var messages strings.Builder
+	ss := &session{
+		Config:  New(),
+		Dialect: d.Dialect,
+		conn:    conn,
+		history: &history.History{},
+		stdOut:  &messages,
+		stdErr:  &messages,
+	}
+	ss.current = &connection{name: mainConnection, driver: "flaky", db: db}
+	ss.connections = []*connection{ss.current}
+	defer ss.Close()
+
+	breakConnection := func() {
+		flaky.breakNext = true
+	}
+	execute := func(query string) error {
+		_, err := ss.executeReconnecting(ctx, query, &scriptIn{})
+		return err
+	}
+	if err := execute("CREATE TABLE T ( ID INTEGER )"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Outside of a transaction, the statement is executed again.
+	breakConnection()
+	if err := execute("INSERT INTO T VALUES (1)"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := execute("COMMIT"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.Contains(messages.String(), "Reconnected to flaky as main") {
+		t.Fatalf("not reconnected: %s", messages.String())
+	}
+
+	// The lost transaction is reported and the statement is not retried.
+	messages.Reset()
+	if err := execute("INSERT INTO T VALUES (2)"); err != nil {
+		t.Fatal(err.Error())
+	}
+	breakConnection()
+	if err := execute("INSERT INTO T VALUES (3)"); err == nil || !isConnectionError(err) {
+		t.Fatalf("expect a connection error, but %v", err)
+	}
+	if ss.tx != nil || !strings.Contains(messages.String(), ErrTransactionLost.Error()) {
+		t.Fatalf("the lost transaction is not reported: %s", messages.String())
+	}
+
+	var count int
+	if err := ss.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM T").Scan(&count); err != nil {
+		t.Fatal(err.Error())
+	}
+	if count != 1 {
+		t.Fatalf("expect 1 row, but %d", count)
+	}
+
+	// The SET outside of a transaction is replayed on the new connection,
+	// but the one in a transaction is not.
+	if err := execute("SET search_path TO app"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := execute("INSERT INTO T VALUES (4)"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := execute("SET LOCAL lock_timeout = 1000"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := execute("ROLLBACK"); err != nil {
+		t.Fatal(err.Error())
+	}
+	flaky.sets = nil
+	breakConnection()
+	if err := execute("SELECT * FROM T"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if expected := "SET search_path TO app"; len(flaky.sets) != 1 || flaky.sets[0] != expected {
+		t.Fatalf("expect %q replayed, but %q", expected, flaky.sets)
+	}
+}
+
+func TestIsSessionSetup(t *testing.T) {
+	for query, expected := range map[string]bool{
+		"SET search_path TO app":                    true,
+		"USE sales":                                 true,
+		"ALTER SESSION SET CURRENT_SCHEMA = app":    true,
+		"ALTER TABLE T ADD COLUMN NAME VARCHAR(10)": false,
+		"INSERT INTO T VALUES (1)":                  false,
+	} {
+		cmd, arg, _ := strings.Cut(query, " ")
+		if isSessionSetup(cmd, arg) != expected {
+			t.Errorf("%s: expect %v", query, expected)
+		}
+	}
+}
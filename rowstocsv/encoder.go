@@ -0,0 +1,611 @@
+package rowstocsv
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+var (
+	ErrUnknownFormat     = errors.New("unknown format")
+	ErrTableNotSpecified = errors.New("the table name for INSERT statements is not specified")
+)
+
+// Encoder writes rows in a format. WriteHeader is called once before
+// the rows and Flush after the last row.
+type Encoder interface {
+	// WriteHeader receives the names and the types of the columns.
+	// columnTypes is nil when they are not available.
+	WriteHeader(columns []string, columnTypes []*sql.ColumnType) error
+
+	// WriteRow receives the values scanned from a row. NULL is nil.
+	WriteRow(values []any) error
+
+	Flush() error
+}
+
+// Formats are the names of the formats available with NewEncoder.
+var Formats = []string{"csv", "tsv", "json", "jsonl", "markdown", "html", "text", "insert", "xlsx"}
+
+// NewEncoder returns the encoder of the format writing to w.
+// For "insert", Table and Dialect of cfg are used.
+func NewEncoder(format string, w io.Writer, cfg Config) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		if cfg.Comma == 0 {
+			cfg.Comma = ','
+		}
+		return cfg.newCSVEncoder(w), nil
+	case "tsv":
+		cfg.Comma = '\t'
+		return cfg.newCSVEncoder(w), nil
+	case "json":
+		return &jsonEncoder{w: w, nl: cfg.newline(), array: true}, nil
+	case "jsonl":
+		return &jsonEncoder{w: w, nl: cfg.newline()}, nil
+	case "markdown", "md":
+		return &markdownEncoder{textEncoder: cfg.newTextEncoder(w)}, nil
+	case "html":
+		return &htmlEncoder{textEncoder: cfg.newTextEncoder(w)}, nil
+	case "text":
+		return &alignedEncoder{textEncoder: cfg.newTextEncoder(w)}, nil
+	case "insert":
+		if cfg.Table == "" {
+			return nil, ErrTableNotSpecified
+		}
+		d := cfg.Dialect
+		if d == nil {
+			d = &dialect.Entry{}
+		}
+		return &insertEncoder{w: w, nl: cfg.newline(), table: cfg.Table, dialect: d}, nil
+	case "xlsx":
+		enc, err := newXlsxEncoder(w)
+		if err != nil {
+			return nil, err
+		}
+		return enc, nil
+	}
+	return nil, fmt.Errorf("%w: %s (expected %s)", ErrUnknownFormat, format, strings.Join(Formats, ", "))
+}
+
+func (cfg Config) newline() string {
+	if cfg.UseCRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// Encode writes the rows with the encoder.
+func (cfg Config) Encode(ctx context.Context, rows Source, enc Encoder) error {
+	if cfg.AutoClose {
+		defer rows.Close()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("(sql.Rows) Columns: %w", err)
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil || len(columnTypes) < len(columns) {
+		columnTypes = nil
+	}
+	if err := enc.WriteHeader(columns, columnTypes); err != nil {
+		return err
+	}
+	refs, data := makeBuffers[any](len(columns))
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := rows.Scan(refs...); err != nil {
+			return err
+		}
+		if err := enc.WriteRow(data); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("(sql.Rows) Err: %w", err)
+	}
+	return enc.Flush()
+}
+
+type teeSource struct {
+	Source
+	enc     Encoder
+	started bool
+	err     error
+}
+
+// Tee returns a Source which also writes the rows read through it with enc.
+// The errors of enc are returned by Close.
+func Tee(rows Source, enc Encoder) Source {
+	return &teeSource{Source: rows, enc: enc}
+}
+
+func (t *teeSource) start() {
+	if t.started {
+		return
+	}
+	t.started = true
+	columns, err := t.Source.Columns()
+	if err != nil {
+		t.err = err
+		return
+	}
+	columnTypes, err := t.Source.ColumnTypes()
+	if err != nil || len(columnTypes) < len(columns) {
+		columnTypes = nil
+	}
+	t.err = t.enc.WriteHeader(columns, columnTypes)
+}
+
+func (t *teeSource) Scan(dest ...any) error {
+	if err := t.Source.Scan(dest...); err != nil {
+		return err
+	}
+	t.start()
+	if t.err != nil {
+		return nil
+	}
+	values := make([]any, len(dest))
+	for i, d := range dest {
+		if p, ok := d.(*any); ok {
+			values[i] = *p
+		}
+	}
+	t.err = t.enc.WriteRow(values)
+	return nil
+}
+
+func (t *teeSource) Close() error {
+	t.start()
+	if t.err == nil {
+		t.err = t.enc.Flush()
+	}
+	return errors.Join(t.Source.Close(), t.err)
+}
+
+func typeAt(columnTypes []*sql.ColumnType, i int) *sql.ColumnType {
+	if i < len(columnTypes) {
+		return columnTypes[i]
+	}
+	return nil
+}
+
+func isNumericColumn(ct *sql.ColumnType) bool {
+	return ct != nil && dialect.IsNumericTypeName(strings.ToUpper(ct.DatabaseTypeName()))
+}
+
+var rxNumber = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][-+]?[0-9]+)?$`)
+
+// numberText returns the text of the value when it is a number written
+// as a text such as DECIMAL values scanned as []byte.
+func numberText(v any) (string, bool) {
+	var s string
+	switch x := v.(type) {
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	default:
+		return "", false
+	}
+	s = strings.TrimSpace(s)
+	return s, rxNumber.MatchString(s)
+}
+
+// csvEncoder is the encoder used by Dump.
+type csvEncoder struct {
+	w           *csv.Writer
+	conv        func(int, *sql.ColumnType, sql.NullString) string
+	debug       bool
+	columnTypes []*sql.ColumnType
+	strs        []string
+}
+
+func (cfg Config) newCSVEncoder(w io.Writer) *csvEncoder {
+	csvw := csv.NewWriter(w)
+	csvw.Comma = cfg.Comma
+	csvw.UseCRLF = cfg.UseCRLF
+	conv := cfg.defaultConv
+	if cfg.Conv != nil {
+		conv = cfg.Conv
+	}
+	return &csvEncoder{w: csvw, conv: conv, debug: cfg.Debug}
+}
+
+func (c *csvEncoder) WriteHeader(columns []string, columnTypes []*sql.ColumnType) error {
+	if err := c.w.Write(columns); err != nil {
+		return err
+	}
+	c.strs = make([]string, len(columns))
+	c.columnTypes = columnTypes
+	if columnTypes == nil {
+		c.columnTypes = make([]*sql.ColumnType, len(columns))
+	} else if c.debug {
+		for i, ct := range columnTypes {
+			if ct != nil {
+				var buffer strings.Builder
+				buffer.WriteString(ct.DatabaseTypeName())
+				if st := ct.ScanType(); st != nil {
+					buffer.WriteByte('(')
+					buffer.WriteString(st.String())
+					buffer.WriteByte(')')
+				}
+				c.strs[i] = buffer.String()
+			} else {
+				c.strs[i] = ""
+			}
+		}
+		c.w.Write(c.strs)
+	}
+	return nil
+}
+
+func (c *csvEncoder) WriteRow(values []any) error {
+	for i, v := range values {
+		c.strs[i] = c.conv(i, c.columnTypes[i], anyToNullString(v))
+	}
+	if err := c.w.Write(c.strs); err != nil {
+		return fmt.Errorf("(csv.Writer).Write: %w", err)
+	}
+	return nil
+}
+
+func (c *csvEncoder) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonEncoder writes rows as JSON objects. Numbers are written as numbers,
+// NULL as null and timestamps in RFC 3339.
+type jsonEncoder struct {
+	w           io.Writer
+	nl          string
+	array       bool
+	columns     []string
+	columnTypes []*sql.ColumnType
+	count       int
+	buffer      bytes.Buffer
+}
+
+func (j *jsonEncoder) WriteHeader(columns []string, columnTypes []*sql.ColumnType) error {
+	j.columns = columns
+	j.columnTypes = columnTypes
+	if j.array {
+		_, err := io.WriteString(j.w, "["+j.nl)
+		return err
+	}
+	return nil
+}
+
+func jsonValue(v any, ct *sql.ColumnType) any {
+	switch x := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return x
+	case float32:
+		return jsonValue(float64(x), ct)
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return strconv.FormatFloat(x, 'g', -1, 64)
+		}
+		return x
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case []byte, string:
+		if s, ok := numberText(x); ok && isNumericColumn(ct) {
+			return json.Number(s)
+		}
+		if b, ok := x.([]byte); ok {
+			return string(b)
+		}
+		return x
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func (j *jsonEncoder) WriteRow(values []any) error {
+	j.buffer.Reset()
+	enc := json.NewEncoder(&j.buffer)
+	enc.SetEscapeHTML(false)
+	if j.array && j.count > 0 {
+		j.buffer.WriteByte(',')
+		j.buffer.WriteString(j.nl)
+	}
+	j.buffer.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			j.buffer.WriteByte(',')
+		}
+		if err := enc.Encode(j.columns[i]); err != nil {
+			return err
+		}
+		j.buffer.Truncate(j.buffer.Len() - 1)
+		j.buffer.WriteByte(':')
+		if err := enc.Encode(jsonValue(v, typeAt(j.columnTypes, i))); err != nil {
+			return err
+		}
+		j.buffer.Truncate(j.buffer.Len() - 1)
+	}
+	j.buffer.WriteByte('}')
+	if !j.array {
+		j.buffer.WriteString(j.nl)
+	}
+	j.count++
+	_, err := j.w.Write(j.buffer.Bytes())
+	return err
+}
+
+func (j *jsonEncoder) Flush() error {
+	if !j.array {
+		return nil
+	}
+	end := "]" + j.nl
+	if j.count > 0 {
+		end = j.nl + end
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+// textEncoder is the common part of the encoders writing values as texts.
+type textEncoder struct {
+	w           io.Writer
+	nl          string
+	null        string
+	conv        func(int, *sql.ColumnType, sql.NullString) string
+	columns     []string
+	columnTypes []*sql.ColumnType
+}
+
+func (cfg Config) newTextEncoder(w io.Writer) textEncoder {
+	conv := cfg.defaultConv
+	if cfg.Conv != nil {
+		conv = cfg.Conv
+	}
+	return textEncoder{w: w, nl: cfg.newline(), null: cfg.Null, conv: conv}
+}
+
+func (t *textEncoder) WriteHeader(columns []string, columnTypes []*sql.ColumnType) error {
+	t.columns = columns
+	t.columnTypes = columnTypes
+	return nil
+}
+
+func (t *textEncoder) texts(values []any) []string {
+	texts := make([]string, len(values))
+	for i, v := range values {
+		texts[i] = t.conv(i, typeAt(t.columnTypes, i), anyToNullString(v))
+	}
+	return texts
+}
+
+// rightAligned reports whether the column is aligned to the right.
+func (t *textEncoder) rightAligned(i int) bool {
+	return isNumericColumn(typeAt(t.columnTypes, i))
+}
+
+// markdownEncoder writes rows as a table of GitHub Flavored Markdown.
+type markdownEncoder struct {
+	textEncoder
+}
+
+var markdownEscaper = strings.NewReplacer(`|`, `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+func (m *markdownEncoder) writeLine(cells []string) error {
+	var b strings.Builder
+	b.WriteString("|")
+	for _, c := range cells {
+		b.WriteString(" ")
+		b.WriteString(c)
+		b.WriteString(" |")
+	}
+	b.WriteString(m.nl)
+	_, err := io.WriteString(m.w, b.String())
+	return err
+}
+
+func (m *markdownEncoder) WriteHeader(columns []string, columnTypes []*sql.ColumnType) error {
+	m.textEncoder.WriteHeader(columns, columnTypes)
+	cells := make([]string, len(columns))
+	for i, c := range columns {
+		cells[i] = markdownEscaper.Replace(c)
+	}
+	if err := m.writeLine(cells); err != nil {
+		return err
+	}
+	for i := range cells {
+		if m.rightAligned(i) {
+			cells[i] = "--:"
+		} else {
+			cells[i] = "---"
+		}
+	}
+	return m.writeLine(cells)
+}
+
+func (m *markdownEncoder) WriteRow(values []any) error {
+	cells := m.texts(values)
+	for i, c := range cells {
+		cells[i] = markdownEscaper.Replace(c)
+	}
+	return m.writeLine(cells)
+}
+
+func (m *markdownEncoder) Flush() error {
+	return nil
+}
+
+// htmlEncoder writes rows as a table of HTML.
+type htmlEncoder struct {
+	textEncoder
+}
+
+func (h *htmlEncoder) writeLine(tag string, cells []string) error {
+	var b strings.Builder
+	b.WriteString("<tr>")
+	for i, c := range cells {
+		if tag == "td" && h.rightAligned(i) {
+			b.WriteString(`<td align="right">`)
+		} else {
+			fmt.Fprintf(&b, "<%s>", tag)
+		}
+		b.WriteString(html.EscapeString(c))
+		fmt.Fprintf(&b, "</%s>", tag)
+	}
+	b.WriteString("</tr>")
+	b.WriteString(h.nl)
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *htmlEncoder) WriteHeader(columns []string, columnTypes []*sql.ColumnType) error {
+	h.textEncoder.WriteHeader(columns, columnTypes)
+	if _, err := io.WriteString(h.w, "<table>"+h.nl); err != nil {
+		return err
+	}
+	return h.writeLine("th", columns)
+}
+
+func (h *htmlEncoder) WriteRow(values []any) error {
+	return h.writeLine("td", h.texts(values))
+}
+
+func (h *htmlEncoder) Flush() error {
+	_, err := io.WriteString(h.w, "</table>"+h.nl)
+	return err
+}
+
+// alignedEncoder writes rows as fixed-width text. Because the widths are
+// decided by all the rows, the rows are kept until Flush.
+type alignedEncoder struct {
+	textEncoder
+	rows [][]string
+}
+
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+func (a *alignedEncoder) WriteRow(values []any) error {
+	texts := a.texts(values)
+	for i, s := range texts {
+		texts[i] = lineBreaks.Replace(s)
+	}
+	a.rows = append(a.rows, texts)
+	return nil
+}
+
+func (a *alignedEncoder) Flush() error {
+	widths := make([]int, len(a.columns))
+	for i, c := range a.columns {
+		widths[i] = runewidth.StringWidth(c)
+	}
+	for _, row := range a.rows {
+		for i, s := range row {
+			if w := runewidth.StringWidth(s); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	var b strings.Builder
+	writeLine := func(cells []string, rightAligned func(int) bool) error {
+		b.Reset()
+		for i, s := range cells {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			padding := strings.Repeat(" ", widths[i]-runewidth.StringWidth(s))
+			if rightAligned(i) {
+				b.WriteString(padding)
+				b.WriteString(s)
+			} else if i < len(cells)-1 {
+				b.WriteString(s)
+				b.WriteString(padding)
+			} else {
+				b.WriteString(s)
+			}
+		}
+		b.WriteString(a.nl)
+		_, err := io.WriteString(a.w, b.String())
+		return err
+	}
+	left := func(int) bool { return false }
+	if err := writeLine(a.columns, left); err != nil {
+		return err
+	}
+	rules := make([]string, len(widths))
+	for i, w := range widths {
+		rules[i] = strings.Repeat("-", w)
+	}
+	if err := writeLine(rules, left); err != nil {
+		return err
+	}
+	for _, row := range a.rows {
+		if err := writeLine(row, a.rightAligned); err != nil {
+			return err
+		}
+	}
+	a.rows = nil
+	return nil
+}
+
+// insertEncoder writes rows as INSERT statements with the literals
+// of the dialect.
+type insertEncoder struct {
+	w           io.Writer
+	nl          string
+	table       string
+	dialect     *dialect.Entry
+	prefix      string
+	columnTypes []*sql.ColumnType
+}
+
+func (e *insertEncoder) WriteHeader(columns []string, columnTypes []*sql.ColumnType) error {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = e.dialect.EncloseIdentifierIfNeed(c)
+	}
+	e.prefix = fmt.Sprintf("INSERT INTO %s (%s) VALUES (", e.table, strings.Join(names, ", "))
+	e.columnTypes = columnTypes
+	return nil
+}
+
+func (e *insertEncoder) WriteRow(values []any) error {
+	var b strings.Builder
+	b.WriteString(e.prefix)
+	for i, v := range values {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if s, ok := numberText(v); ok && isNumericColumn(typeAt(e.columnTypes, i)) {
+			b.WriteString(s)
+		} else {
+			b.WriteString(e.dialect.QuoteLiteral(v))
+		}
+	}
+	b.WriteString(");")
+	b.WriteString(e.nl)
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *insertEncoder) Flush() error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package rowstocsv
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncoders(t *testing.T) {
+	records := [][]string{
+		{"ID", "NAME"},
+		{"1", "a|b"},
+		{"22", "<c>"},
+	}
+	for _, c := range []struct {
+		format string
+		expect string
+	}{
+		{
+			format: "jsonl",
+			expect: `{"ID":"1","NAME":"a|b"}` + "\n" + `{"ID":"22","NAME":"<c>"}` + "\n",
+		},
+		{
+			format: "markdown",
+			expect: "| ID | NAME |\n| --- | --- |\n| 1 | a\\|b |\n| 22 | <c> |\n",
+		},
+		{
+			format: "html",
+			expect: "<table>\n<tr><th>ID</th><th>NAME</th></tr>\n" +
+				"<tr><td>1</td><td>a|b</td></tr>\n<tr><td>22</td><td>&lt;c&gt;</td></tr>\n</table>\n",
+		},
+		{
+			format: "text",
+			expect: "ID NAME\n-- ----\n1  a|b\n22 <c>\n",
+		},
+		{
+			format: "insert",
+			expect: "INSERT INTO T (ID, NAME) VALUES ('1', 'a|b');\n" +
+				"INSERT INTO T (ID, NAME) VALUES ('22', '<c>');\n",
+		},
+	} {
+		var buffer strings.Builder
+		enc, err := NewEncoder(c.format, &buffer, Config{Table: "T"})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := (Config{}).Encode(context.Background(), NewRecords(records), enc); err != nil {
+			t.Fatal(err.Error())
+		}
+		if got := buffer.String(); got != c.expect {
+			t.Errorf("%s: expect %q, but %q", c.format, c.expect, got)
+		}
+	}
+	if _, err := NewEncoder("insert", &strings.Builder{}, Config{}); err != ErrTableNotSpecified {
+		t.Errorf("expect ErrTableNotSpecified, but %v", err)
+	}
+}
+
+func TestEncodersWithTypedValues(t *testing.T) {
+	columns := []string{"I", "F", "T", "B", "N"}
+	values := []any{
+		int64(7),
+		1.5,
+		time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		[]byte("it's"),
+		nil,
+	}
+	for _, c := range []struct {
+		format string
+		expect string
+	}{
+		{
+			format: "jsonl",
+			expect: `{"I":7,"F":1.5,"T":"2024-01-02T03:04:05Z","B":"it's","N":null}` + "\n",
+		},
+		{
+			format: "json",
+			expect: "[\n" + `{"I":7,"F":1.5,"T":"2024-01-02T03:04:05Z","B":"it's","N":null}` + "\n]\n",
+		},
+		{
+			format: "insert",
+			expect: "INSERT INTO T (I, F, T, B, N) VALUES (7, 1.5, '2024-01-02 03:04:05', 'it''s', NULL);\n",
+		},
+	} {
+		var buffer strings.Builder
+		enc, err := NewEncoder(c.format, &buffer, Config{Table: "T"})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := enc.WriteHeader(columns, nil); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := enc.WriteRow(values); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := enc.Flush(); err != nil {
+			t.Fatal(err.Error())
+		}
+		if got := buffer.String(); got != c.expect {
+			t.Errorf("%s: expect %q, but %q", c.format, c.expect, got)
+		}
+	}
+}
@@ -3,11 +3,11 @@ package rowstocsv
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"fmt"
 	"io"
-	"strings"
 	"time"
+
+	"github.com/hymkor/sqlbless/dialect"
 )
 
 type Source interface {
@@ -43,68 +43,6 @@ func makeBuffers[T any](n int) ([]any, []T) {
 	return refs, data
 }
 
-func dump(ctx context.Context, rows Source, conv func(int, *sql.ColumnType, sql.NullString) string, debug bool, write func([]string) error) error {
-	columns, err := rows.Columns()
-	if err != nil {
-		return fmt.Errorf("(sql.Rows) Columns: %w", err)
-	}
-
-	if err := write(columns); err != nil {
-		return err
-	}
-
-	n := len(columns)
-	refs, data := makeBuffers[any](n)
-	//refs, data := makeBuffers[sql.RawBytes](n)
-	strs := make([]string, len(columns))
-
-	columnTypes, err := rows.ColumnTypes()
-	if err != nil {
-		for i := 0; i < n; i++ {
-			columnTypes[i] = nil
-		}
-	} else if debug {
-		for i, c := range columnTypes {
-			if c != nil {
-				var buffer strings.Builder
-				buffer.WriteString(c.DatabaseTypeName())
-				if st := c.ScanType(); st != nil {
-					buffer.WriteByte('(')
-					buffer.WriteString(st.String())
-					buffer.WriteByte(')')
-				}
-				strs[i] = buffer.String()
-			} else {
-				strs[i] = ""
-			}
-
-		}
-		write(strs)
-	}
-
-	for rows.Next() {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-		if err := rows.Scan(refs...); err != nil {
-			return err
-		}
-		for i, v := range data {
-			ns := anyToNullString(v)
-			strs[i] = conv(i, columnTypes[i], ns)
-		}
-		if err := write(strs); err != nil {
-			return fmt.Errorf("(csv.Writer).Write: %w", err)
-		}
-	}
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("(sql.Rows) Err: %w", err)
-	}
-	return nil
-}
-
 type Config struct {
 	Comma     rune
 	UseCRLF   bool
@@ -112,6 +50,10 @@ type Config struct {
 	Debug     bool
 	Conv      func(int, *sql.ColumnType, sql.NullString) string
 	AutoClose bool
+
+	// Table and Dialect are used by the encoder of INSERT statements.
+	Table   string
+	Dialect *dialect.Entry
 }
 
 func (cfg Config) defaultConv(_ int, _ *sql.ColumnType, v sql.NullString) string {
@@ -122,18 +64,7 @@ func (cfg Config) defaultConv(_ int, _ *sql.ColumnType, v sql.NullString) string
 }
 
 func (cfg Config) Dump(ctx context.Context, rows Source, w io.Writer) error {
-	csvw := csv.NewWriter(w)
-	defer csvw.Flush()
-
-	csvw.Comma = cfg.Comma
-	csvw.UseCRLF = cfg.UseCRLF
-
-	conv := cfg.defaultConv
-	if cfg.Conv != nil {
-		conv = cfg.Conv
-	}
-	if cfg.AutoClose {
-		defer rows.Close()
-	}
-	return dump(ctx, rows, conv, cfg.Debug, csvw.Write)
+	enc := cfg.newCSVEncoder(w)
+	defer enc.w.Flush()
+	return cfg.Encode(ctx, rows, enc)
 }
@@ -0,0 +1,73 @@
+package rowstocsv
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var ErrColumnTypesNotAvailable = errors.New("column types are not available")
+
+// Records is a Source reading the records on memory.
+// The first record is used as the header.
+type Records struct {
+	records [][]string
+	current int
+}
+
+func NewRecords(records [][]string) *Records {
+	return &Records{records: records}
+}
+
+func (r *Records) Close() error {
+	r.current = len(r.records)
+	return nil
+}
+
+func (r *Records) ColumnTypes() ([]*sql.ColumnType, error) {
+	return nil, ErrColumnTypesNotAvailable
+}
+
+func (r *Records) Columns() ([]string, error) {
+	if len(r.records) <= 0 {
+		return []string{}, nil
+	}
+	return r.records[0], nil
+}
+
+func (r *Records) Err() error {
+	return nil
+}
+
+func (r *Records) Next() bool {
+	if r.current+1 >= len(r.records) {
+		r.current = len(r.records)
+		return false
+	}
+	r.current++
+	return true
+}
+
+func (r *Records) Scan(dest ...any) error {
+	if r.current <= 0 || r.current >= len(r.records) {
+		return sql.ErrNoRows
+	}
+	record := r.records[r.current]
+	for i, d := range dest {
+		var value string
+		if i < len(record) {
+			value = record[i]
+		}
+		switch p := d.(type) {
+		case *any:
+			*p = value
+		case *string:
+			*p = value
+		case *sql.NullString:
+			*p = sql.NullString{String: value, Valid: true}
+		default:
+			return fmt.Errorf("Scan: unsupported type %T", d)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package rowstocsv
+
+import (
+	"database/sql"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hymkor/sqlbless/internal/xlsx"
+)
+
+// xlsxEncoder writes rows to a worksheet. The columns of numbers and
+// dates are written as typed cells by the types of the columns.
+type xlsxEncoder struct {
+	w           *xlsx.Writer
+	columnTypes []*sql.ColumnType
+}
+
+func newXlsxEncoder(w io.Writer) (*xlsxEncoder, error) {
+	xw, err := xlsx.NewWriter(w, "Sheet1")
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxEncoder{w: xw}, nil
+}
+
+func (x *xlsxEncoder) WriteHeader(columns []string, columnTypes []*sql.ColumnType) error {
+	x.columnTypes = columnTypes
+	header := make([]any, len(columns))
+	for i, c := range columns {
+		header[i] = c
+	}
+	return x.w.WriteRow(header)
+}
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func isDateColumn(ct *sql.ColumnType) bool {
+	if ct == nil {
+		return false
+	}
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	return (strings.Contains(name, "DATE") || strings.Contains(name, "TIME")) &&
+		!strings.Contains(name, "INTERVAL")
+}
+
+// dateValue parses the text of a date written as a text by the driver.
+func dateValue(v any) (time.Time, bool) {
+	var s string
+	switch x := v.(type) {
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	default:
+		return time.Time{}, false
+	}
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (x *xlsxEncoder) WriteRow(values []any) error {
+	cells := make([]any, len(values))
+	for i, v := range values {
+		ct := typeAt(x.columnTypes, i)
+		if s, ok := numberText(v); ok && isNumericColumn(ct) {
+			cells[i] = xlsx.Number(s)
+		} else if t, ok := dateValue(v); ok && isDateColumn(ct) {
+			cells[i] = t
+		} else if b, ok := v.([]byte); ok {
+			cells[i] = string(b)
+		} else {
+			cells[i] = v
+		}
+	}
+	return x.w.WriteRow(cells)
+}
+
+func (x *xlsxEncoder) Flush() error {
+	return x.w.Close()
+}
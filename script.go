@@ -7,17 +7,28 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
+
+	"github.com/nyaosorg/go-readline-ny"
+
 	"github.com/hymkor/csvi"
 
+	"github.com/hymkor/sqlbless/dialect"
 	"github.com/hymkor/sqlbless/internal/misc"
+	"github.com/hymkor/sqlbless/internal/sqllex"
 )
 
 type scriptIn struct {
-	br   *bufio.Reader
-	echo io.Writer
-	term string
+	br      *bufio.Reader
+	echo    func(code string)
+	term    string
+	syntax  dialect.Syntax
+	pending string
 }
 
 func (*scriptIn) CanCloseInTransaction() bool                 { return true }
@@ -25,6 +36,21 @@ func (*scriptIn) ShouldRecordHistory() bool                   { return false }
 func (*scriptIn) SetPrompt(func(io.Writer, int) (int, error)) {}
 func (*scriptIn) OnErrorAbort() bool                          { return true }
 
+// ReadLine asks a value on the terminal, because the script itself
+// can not answer it.
+func (script *scriptIn) ReadLine(ctx context.Context, prompt string) (string, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("%s: %w", strings.TrimSpace(prompt), ErrCanNotAskValue)
+	}
+	editor := &readline.Editor{
+		Writer: colorable.NewColorableStdout(),
+		PromptWriter: func(w io.Writer) (int, error) {
+			return io.WriteString(w, prompt)
+		},
+	}
+	return editor.ReadLine(ctx)
+}
+
 func (script *scriptIn) GetKey() (string, error) {
 	return "", io.EOF
 }
@@ -33,65 +59,174 @@ func (script *scriptIn) AutoPilotForCsvi() (csvi.Pilot, bool) {
 	return &misc.CsviNoOperation{}, true
 }
 
+// Read returns the next statement. It reads lines until a terminator
+// outside strings, quoted identifiers, comments and blocks is found.
 func (script *scriptIn) Read(context.Context) ([]string, error) {
-	if script.br == nil {
-		return nil, io.EOF
-	}
-	var buffer strings.Builder
-	quoted := 0
 	for {
-		ch, _, err := script.br.ReadRune()
-		if errors.Is(err, io.EOF) {
-			code := buffer.String()
-			fmt.Fprintln(script.echo, strings.TrimSpace(code))
-			script.br = nil
+		if end := sqllex.Split(script.pending, script.term, script.syntax); end >= 0 {
+			code := script.pending[:end]
+			script.pending = script.pending[end:]
+			script.echo(strings.TrimSpace(code))
 			return []string{code}, nil
 		}
-		if err != nil {
+		if script.br == nil {
+			if strings.TrimSpace(script.pending) == "" {
+				return nil, io.EOF
+			}
+			code := script.pending
+			script.pending = ""
+			script.echo(strings.TrimSpace(code))
+			return []string{code}, nil
+		}
+		line, err := script.br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
 			return nil, err
 		}
-		if ch == '\r' {
-			continue
-		} else if ch == '\'' {
-			quoted ^= 1
-		} else if ch == '"' {
-			quoted ^= 2
+		script.pending += strings.ReplaceAll(line, "\r", "")
+		if err != nil {
+			script.br = nil
 		}
-		buffer.WriteRune(ch)
-
-		if quoted == 0 {
-			code := buffer.String()
-			term := script.term
-			if _, ok := misc.HasTerm(code, term); ok {
-				fmt.Fprintln(script.echo, strings.TrimSpace(code))
-				return []string{code}, nil
-			}
+	}
+}
+
+// echoScript returns the function to show the statements read from the
+// script unless SET ECHO OFF. They are written into ss.stdErr at the start
+// of the script, which includes the spool when it is spooling.
+func (ss *session) echoScript() func(string) {
+	w := ss.stdErr
+	return func(code string) {
+		if !ss.echoOff {
+			fmt.Fprintln(w, redactDataSources(code, ss.Dialect.Syntax))
 		}
 	}
 }
 
 func (ss *session) StartFromStdin(ctx context.Context) error {
 	script := &scriptIn{
-		br:   bufio.NewReader(os.Stdin),
-		echo: ss.stdErr,
-		term: ss.Term,
+		br:     bufio.NewReader(os.Stdin),
+		echo:   ss.echoScript(),
+		term:   ss.Term,
+		syntax: ss.Dialect.Syntax,
 	}
 	return ss.Loop(ctx, script)
 }
 
-func (ss *session) Start(ctx context.Context, fname string) error {
+var (
+	ErrNoScriptName    = errors.New("invalid syntax: expected 'START filename [arg ...]'")
+	ErrRecursiveScript = errors.New("recursive script inclusion")
+)
+
+// resolveScript returns the path of the script fname. A relative path is
+// resolved from the directory of the calling script for `@@`, and from
+// the current directory and then the directories of SQLPATH for START
+// and `@` like SQL*Plus.
+func (ss *session) resolveScript(fname string, nested bool) string {
+	if filepath.IsAbs(fname) {
+		return withSQLExtension(fname)
+	}
+	if nested && len(ss.scripts) > 0 {
+		return withSQLExtension(filepath.Join(filepath.Dir(ss.scripts[len(ss.scripts)-1]), fname))
+	}
+	if path := withSQLExtension(fname); fileExists(path) {
+		return path
+	}
+	for _, dir := range filepath.SplitList(os.Getenv("SQLPATH")) {
+		if path := withSQLExtension(filepath.Join(dir, fname)); fileExists(path) {
+			return path
+		}
+	}
+	return withSQLExtension(fname)
+}
+
+// withSQLExtension appends `.sql` to fname when the file does not exist
+// and has no extension.
+func withSQLExtension(fname string) string {
+	if filepath.Ext(fname) == "" && !fileExists(fname) && fileExists(fname+".sql") {
+		return fname + ".sql"
+	}
+	return fname
+}
+
+func fileExists(fname string) bool {
+	_, err := os.Stat(fname)
+	return err == nil
+}
+
+// setPositionalArgs defines the substitution variables `&1`, `&2` ... as
+// args and returns the function to restore the previous ones.
+func (ss *session) setPositionalArgs(args []string) func() {
+	saved := map[string]string{}
+	for name, value := range ss.defines {
+		if _, err := strconv.Atoi(name); err == nil {
+			saved[name] = value
+			delete(ss.defines, name)
+		}
+	}
+	for i, value := range args {
+		ss.define(strconv.Itoa(i+1), value)
+	}
+	return func() {
+		for name := range ss.defines {
+			if _, err := strconv.Atoi(name); err == nil {
+				delete(ss.defines, name)
+			}
+		}
+		for name, value := range saved {
+			ss.define(name, value)
+		}
+	}
+}
+
+// doStart runs the script with the arguments of the START (or @, @@) command.
+// nested is true for `@@`.
+func doStart(ctx context.Context, ss *session, arg string, nested bool) error {
+	fname, arg := cutWord(arg)
+	if fname == "" {
+		return ErrNoScriptName
+	}
+	var args []string
+	for strings.TrimSpace(arg) != "" {
+		var value string
+		value, arg = cutWord(arg)
+		args = append(args, value)
+	}
+	return ss.Start(ctx, ss.resolveScript(fname, nested), args...)
+}
+
+// Start runs the script fname. args are available as `&1`, `&2` ...
+// in the script.
+func (ss *session) Start(ctx context.Context, fname string, args ...string) error {
 	if fname == "-" {
 		return ss.StartFromStdin(ctx)
 	}
+	fullpath, err := filepath.Abs(fname)
+	if err != nil {
+		return err
+	}
+	for i, running := range ss.scripts {
+		if running == fullpath {
+			chain := append(append([]string{}, ss.scripts[i:]...), fullpath)
+			for j := range chain {
+				chain[j] = filepath.Base(chain[j])
+			}
+			return fmt.Errorf("%s: %w (%s)", fname, ErrRecursiveScript, strings.Join(chain, " -> "))
+		}
+	}
 	fd, err := os.Open(fname)
 	if err != nil {
 		return err
 	}
 	defer fd.Close()
+
+	ss.scripts = append(ss.scripts, fullpath)
+	defer func() { ss.scripts = ss.scripts[:len(ss.scripts)-1] }()
+	defer ss.setPositionalArgs(args)()
+
 	script := &scriptIn{
-		br:   bufio.NewReader(fd),
-		echo: ss.stdErr,
-		term: ss.Term,
+		br:     bufio.NewReader(fd),
+		echo:   ss.echoScript(),
+		term:   ss.Term,
+		syntax: ss.Dialect.Syntax,
 	}
 	return ss.Loop(ctx, script)
 }
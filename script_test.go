@@ -0,0 +1,181 @@
+package sqlbless
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func writeScripts(t *testing.T, dir string, scripts map[string]string) {
+	t.Helper()
+	for name, body := range scripts {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+}
+
+func runScriptFile(t *testing.T, fname string) error {
+	t.Helper()
+	restoreColor := disableColor()
+	defer restoreColor()
+
+	d, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cfg := New()
+	cfg.Script = fname
+	return cfg.Run(d.Driver, d.DataSource, d.Dialect)
+}
+
+func TestStartWithArguments(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SQLPATH", dir)
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			SPOOL ` + spool + `;
+			CREATE TABLE T ( NAME CHAR VARYING(20) );
+			START sub/child.sql FOO 'BAR BAZ';
+			@sub/child QUX '';
+			SELECT NAME FROM T ORDER BY NAME;
+			SPOOL OFF;
+			ROLLBACK;`,
+		"sub/child.sql": `
+			INSERT INTO T VALUES ('&1');
+			@@grandchild.sql '&2';
+			INSERT INTO T VALUES ('&1');`,
+		"sub/grandchild.sql": `
+			INSERT INTO T VALUES ('(&1)');`,
+	})
+	if err := runScriptFile(t, filepath.Join(dir, "main.sql")); err != nil {
+		t.Fatal(err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			names = append(names, line)
+		}
+	}
+	expect := "NAME|()|(BAR BAZ)|FOO|FOO|QUX|QUX"
+	if got := strings.Join(names, "|"); !strings.HasSuffix(got, expect) {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+}
+
+func TestStartRecursive(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SQLPATH", dir)
+	writeScripts(t, dir, map[string]string{
+		"a.sql": "@b.sql;",
+		"b.sql": "START a.sql;",
+	})
+	err := runScriptFile(t, filepath.Join(dir, "a.sql"))
+	if !errors.Is(err, ErrRecursiveScript) {
+		t.Fatalf("expect ErrRecursiveScript, but %v", err)
+	}
+	if !strings.Contains(err.Error(), "a.sql -> b.sql -> a.sql") {
+		t.Fatalf("the chain of scripts is not shown: %s", err.Error())
+	}
+}
+
+func TestStartResolvesAtAndAtAt(t *testing.T) {
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			SPOOL ` + spool + `;
+			CREATE TABLE T ( NAME CHAR VARYING(20) );
+			@sub/child;
+			SELECT NAME FROM T ORDER BY NAME;
+			SPOOL OFF;
+			ROLLBACK;`,
+		"sub/child.sql": `
+			@@name;
+			@name;
+			START libonly;`,
+		"sub/name.sql":    "INSERT INTO T VALUES ('CALLER');",
+		"name.sql":        "INSERT INTO T VALUES ('CURRENT');",
+		"lib/libonly.sql": "INSERT INTO T VALUES ('SQLPATH');",
+	})
+	t.Setenv("SQLPATH", filepath.Join(dir, "lib"))
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.Chdir(cwd)
+
+	if err := runScriptFile(t, "main.sql"); err != nil {
+		t.Fatal(err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			names = append(names, line)
+		}
+	}
+	expect := "NAME|CALLER|CURRENT|SQLPATH"
+	if got := strings.Join(names, "|"); !strings.HasSuffix(got, expect) {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+}
+
+func TestStatementSplitting(t *testing.T) {
+	records := runSQLiteScript(t, `
+		SPOOL {{SPOOL}};
+		-- don't stop here;
+		/* nor here; */ SELECT 'a;b' AS "x;y", [c;d] FROM (SELECT 1 AS [c;d]);
+		SPOOL OFF;
+	`)
+	expect := "|x;y,c;d|a;b,1|"
+	if got := spooledLines(records); got != expect {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	records := runSQLiteScript(t, `
+		CREATE TABLE T ( ID NUMERIC );
+		CREATE TABLE LOG ( MSG CHAR VARYING(20) );
+		CREATE TRIGGER T_INSERTED AFTER INSERT ON T
+		BEGIN
+			INSERT INTO LOG VALUES ('inserted;' || NEW.ID);
+			INSERT INTO LOG VALUES ('done');
+		END;
+		INSERT INTO T VALUES (1);
+		SPOOL {{SPOOL}}
+		/
+		SELECT MSG FROM LOG ORDER BY MSG
+		/
+		WHENEVER SQLERROR CONTINUE;
+		BEGIN;
+		SPOOL OFF;
+		ROLLBACK;
+	`)
+	expect := "|MSG|done|inserted;1|Ok|'BEGIN' is not supported; transactions are managed automatically|"
+	if got := spooledLines(records); got != expect {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+}
@@ -0,0 +1,83 @@
+package sqlbless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+)
+
+var ErrInvalidOnOff = errors.New("expected ON or OFF")
+
+func parseOnOff(value string) (bool, error) {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "ON":
+		return true, nil
+	case "OFF":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s: %w", value, ErrInvalidOnOff)
+}
+
+// clientOptions are the settings of `SET name value` handled by SQL-Bless
+// itself instead of being sent to the server.
+var clientOptions = map[string]func(ss *session, value string) error{
+	"DEFINE": func(ss *session, value string) error {
+		on, err := parseOnOff(value)
+		if err != nil {
+			return err
+		}
+		ss.noDefine = !on
+		return nil
+	},
+	"TIMING": func(ss *session, value string) error {
+		on, err := parseOnOff(value)
+		if err != nil {
+			return err
+		}
+		ss.Timing = on
+		return nil
+	},
+	"ECHO": func(ss *session, value string) error {
+		on, err := parseOnOff(value)
+		if err != nil {
+			return err
+		}
+		ss.echoOff = !on
+		return nil
+	},
+	"FORMAT": func(ss *session, value string) error {
+		return ss.setFormat(value)
+	},
+	"TIMEOUT": func(ss *session, value string) error {
+		d, err := parseTimeout(value)
+		if err != nil {
+			return err
+		}
+		return ss.setTimeout(context.Background(), d)
+	},
+}
+
+// isClientOption reports whether SET with arg is for an option of the client.
+func isClientOption(arg string) bool {
+	name, _ := misc.CutField(arg)
+	_, ok := clientOptions[strings.ToUpper(name)]
+	return ok
+}
+
+// setClientOption handles `SET name value` for clientOptions.
+// It reports false when the name is not a client option.
+func (ss *session) setClientOption(arg string) (bool, error) {
+	name, value := misc.CutField(arg)
+	f, ok := clientOptions[strings.ToUpper(name)]
+	if !ok {
+		return false, nil
+	}
+	if err := f(ss, value); err != nil {
+		return true, fmt.Errorf("SET %s: %w", strings.ToUpper(name), err)
+	}
+	fmt.Fprintln(ss.stdErr, "Ok")
+	return true, nil
+}
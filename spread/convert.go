@@ -0,0 +1,99 @@
+package spread
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+// Converter converts the texts of a column to the values for the database.
+type Converter struct {
+	// Quote converts the text to the value given to the placeholder.
+	Quote func(string) (any, error)
+
+	// Validate checks the text and returns the text to be stored.
+	// It returns the string for NULL when the text means NULL.
+	Validate func(string) (string, error)
+}
+
+func quoteNumber(s string) (any, error) {
+	if strings.ContainsRune(s, '.') {
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v, nil
+		}
+	} else if v, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return v, nil
+	}
+	return s, nil
+}
+
+func quoteString(s string) (any, error) {
+	return s, nil
+}
+
+// NewConverters returns the converters for the columns built from their
+// types and the converters of the dialect. null is the string for NULL.
+func NewConverters(columnTypes []*sql.ColumnType, entry *dialect.Entry, null string) []Converter {
+	converters := make([]Converter, 0, len(columnTypes))
+	for _, ct := range columnTypes {
+		name := strings.ToUpper(ct.DatabaseTypeName())
+		var c Converter
+		_ct := ct
+		if conv := entry.LookupConverter(name); conv != nil {
+			c.Quote = conv
+			c.Validate = func(s string) (string, error) {
+				if s == null || s == "" {
+					if nullable, ok := _ct.Nullable(); ok && !nullable {
+						return "", ErrColumnIsNotNull
+					}
+					return null, nil
+				}
+				if _, err := conv(s); err != nil {
+					return "", err
+				}
+				return s, nil
+			}
+		} else if dialect.IsNumericTypeName(name) {
+			c.Quote = quoteNumber
+			c.Validate = func(s string) (string, error) {
+				if s == null || s == "" {
+					if nullable, ok := _ct.Nullable(); ok && !nullable {
+						return "", ErrColumnIsNotNull
+					}
+					return null, nil
+				}
+				if _, err := strconv.ParseFloat(s, 64); err != nil {
+					return "", ErrNotANumber
+				}
+				return s, nil
+			}
+		} else {
+			c.Quote = quoteString
+			c.Validate = func(s string) (string, error) {
+				if s == null {
+					if nullable, ok := _ct.Nullable(); ok && !nullable {
+						return "", ErrColumnIsNotNull
+					}
+				}
+				return s, nil
+			}
+		}
+		converters = append(converters, c)
+	}
+	return converters
+}
+
+// Convert validates the text and converts it to the value for the database.
+// The text for NULL is converted to nil.
+func (c Converter) Convert(s, null string) (any, error) {
+	s, err := c.Validate(s)
+	if err != nil {
+		return nil, err
+	}
+	if s == null {
+		return nil, nil
+	}
+	return c.Quote(s)
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 
-	"strconv"
 	"strings"
 
 	"github.com/hymkor/csvi"
@@ -59,7 +58,7 @@ func csvRowIsNew(row *uncsv.Row) bool {
 	return true
 }
 
-func createWhere(row *uncsv.Row, columns []string, quoteFunc []func(string) (any, error), null string, holder dialect.PlaceHolder) (string, error) {
+func createWhere(row *uncsv.Row, columns []string, converters []Converter, null string, holder dialect.PlaceHolder) (string, error) {
 	var where strings.Builder
 	for i, c := range row.Cell {
 		if i > 0 {
@@ -70,7 +69,7 @@ func createWhere(row *uncsv.Row, columns []string, quoteFunc []func(string) (any
 		if string(c.Original()) == null {
 			fmt.Fprintf(&where, "%s is NULL", doubleQuoteIfNeed(columns[i]))
 		} else {
-			v, err := quoteFunc[i](string(c.Original()))
+			v, err := converters[i].Quote(string(c.Original()))
 			if err != nil {
 				return "", err
 			}
@@ -120,74 +119,9 @@ func (editor *Editor) Edit(ctx context.Context, tableAndWhere string, termOut io
 	if err != nil {
 		return err
 	}
-	quoteFunc := make([]func(string) (any, error), 0, len(columnTypes))
-	validateFunc := make([]func(string) (string, error), 0, len(columnTypes))
-	for _, ct := range columnTypes {
-		name := strings.ToUpper(ct.DatabaseTypeName())
-		var v func(string) (string, error)
-		_ct := ct
-		if conv := editor.LookupConverter(name); conv != nil {
-			quoteFunc = append(quoteFunc, conv)
-			v = func(s string) (string, error) {
-				if s == editor.Null || s == "" {
-					if nullable, ok := _ct.Nullable(); ok && !nullable {
-						return "", ErrColumnIsNotNull
-					}
-					return editor.Null, nil
-				}
-				if _, err := conv(s); err != nil {
-					return "", err
-				}
-				return s, nil
-			}
-		} else if strings.Contains(name, "INT") ||
-			strings.Contains(name, "FLOAT") ||
-			strings.Contains(name, "DOUBLE") ||
-			name == "YEAR" ||
-			strings.Contains(name, "REAL") ||
-			strings.Contains(name, "SERIAL") ||
-			strings.Contains(name, "NUMBER") ||
-			strings.Contains(name, "NUMERIC") ||
-			strings.Contains(name, "DECIMAL") {
-			quoteFunc = append(quoteFunc, func(s string) (any, error) {
-				if strings.ContainsRune(s, '.') {
-					if v, err := strconv.ParseFloat(s, 64); err == nil {
-						return v, nil
-					}
-				} else if v, err := strconv.ParseInt(s, 0, 64); err == nil {
-					return v, nil
-				}
-				return s, nil
-			})
-			v = func(s string) (string, error) {
-				if s == editor.Null || s == "" {
-					if nullable, ok := _ct.Nullable(); ok && !nullable {
-						return "", ErrColumnIsNotNull
-					}
-					return editor.Null, nil
-				}
-				if _, err := strconv.ParseFloat(s, 64); err != nil {
-					return "", ErrNotANumber
-				}
-				return s, nil
-			}
-		} else {
-			quoteFunc = append(quoteFunc, func(s string) (any, error) {
-				return s, nil
-			})
-			v = func(s string) (string, error) {
-				if s == editor.Null {
-					if nullable, ok := _ct.Nullable(); ok && !nullable {
-						return "", ErrColumnIsNotNull
-					}
-				}
-				return s, nil
-			}
-		}
-		validateFunc = append(validateFunc, v)
-	}
+	converters := NewConverters(columnTypes, editor.Entry, editor.Null)
 	v := func(e *csvi.CellValidatedEvent) (string, error) {
-		return validateFunc[e.Col](e.Text)
+		return converters[e.Col].Validate(e.Text)
 	}
 
 	changes, err := editor.Viewer.edit(tableAndWhere, v, func(w io.Writer) error {
@@ -224,7 +158,7 @@ func (editor *Editor) Edit(ctx context.Context, tableAndWhere string, termOut io
 					sql.WriteString("NULL")
 				} else {
 					var v any
-					v, err = quoteFunc[i](c.Text())
+					v, err = converters[i].Quote(c.Text())
 					if err != nil {
 						return false
 					}
@@ -248,7 +182,7 @@ func (editor *Editor) Edit(ctx context.Context, tableAndWhere string, termOut io
 							doubleQuoteIfNeed(columns[i]))
 					} else {
 						var v any
-						v, err = quoteFunc[i](c.Text())
+						v, err = converters[i].Quote(c.Text())
 						if err != nil {
 							return false
 						}
@@ -261,7 +195,7 @@ func (editor *Editor) Edit(ctx context.Context, tableAndWhere string, termOut io
 				}
 			}
 			var v string
-			v, err = createWhere(row, columns, quoteFunc, editor.Null, holder)
+			v, err = createWhere(row, columns, converters, editor.Null, holder)
 			if err != nil {
 				return false
 			}
@@ -282,7 +216,7 @@ func (editor *Editor) Edit(ctx context.Context, tableAndWhere string, termOut io
 		var sql strings.Builder
 		fmt.Fprintf(&sql, "DELETE FROM %s", table)
 		var v string
-		v, err = createWhere(row, columns, quoteFunc, editor.Null, holder)
+		v, err = createWhere(row, columns, converters, editor.Null, holder)
 		if err != nil {
 			return false
 		}
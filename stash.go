@@ -0,0 +1,100 @@
+package sqlbless
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hymkor/sqlbless/dialect"
+	"github.com/hymkor/sqlbless/rowstocsv"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+)
+
+var (
+	ErrInvalidStashSyntax = errors.New("invalid syntax: expected 'STASH name select-statement'")
+	ErrNothingStashed     = errors.New("nothing is stashed or attached")
+)
+
+// stash replaces the table of the scratch database with the rows and
+// returns the number of them. The types of the columns are mapped to
+// the ones of SQLite3.
+func (ss *session) stash(ctx context.Context, name string, rows rowstocsv.Source) (int64, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	if err := ss.checkScratchName(ctx, name); err != nil {
+		return 0, err
+	}
+	sc, err := ss.scratchSession(ctx)
+	if err != nil {
+		return 0, err
+	}
+	specs := make([]dialect.ColumnTypeSpec, len(columnTypes))
+	lines := make([]string, len(columns))
+	for i, ct := range columnTypes {
+		specs[i] = dialect.SpecOf(ct)
+		lines[i] = sc.Dialect.EncloseIdentifierIfNeed(columns[i]) + " " + sc.Dialect.TypeNameOf(specs[i])
+	}
+	ddl := fmt.Sprintf("CREATE TABLE %s (\n    %s\n)", name, strings.Join(lines, ",\n    "))
+	var count int64
+	err = ss.scratch.load(ctx, name, ddl, func(tx *sql.Tx) (int64, error) {
+		count, err = sc.copyRows(ctx, tx, rows, name, columns, specs)
+		return count, err
+	})
+	return count, err
+}
+
+// doStash copies the result of the query on the connection into the
+// table of the scratch database to query it with SCRATCH later.
+func doStash(ctx context.Context, ss *session, arg string, commandIn commandIn) error {
+	name, query := misc.CutField(arg)
+	if name == "" || strings.TrimSpace(query) == "" {
+		return ErrInvalidStashSyntax
+	}
+	query, args, err := ss.expandBindVariables(ctx, query, commandIn)
+	if err != nil {
+		return err
+	}
+	echoArgs(ss.spool, args)
+
+	start := time.Now()
+	var rows *sql.Rows
+	if ss.tx != nil {
+		rows, err = ss.tx.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = ss.conn.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	count, err := ss.stash(ctx, name, rows)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ss.stdErr, "%d row(s) stashed as %s\n", count, name)
+	ss.printElapsed("stash", start)
+	return nil
+}
+
+// doScratch executes the statement on the scratch database, where the
+// stashed and attached tables can be joined. Without a statement, the
+// tables are listed.
+func doScratch(ctx context.Context, ss *session, arg string, commandIn commandIn) error {
+	if ss.scratch == nil {
+		return ErrNothingStashed
+	}
+	if strings.TrimSpace(arg) == "" {
+		arg = "DESC"
+	}
+	return ss.executeInScratch(ctx, arg, commandIn)
+}
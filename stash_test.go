@@ -0,0 +1,70 @@
+package sqlbless
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hymkor/sqlbless/dialect"
+)
+
+func TestStash(t *testing.T) {
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "output.lst")
+	writeScripts(t, dir, map[string]string{
+		"main.sql": `
+			CREATE TABLE T ( ID INTEGER, AMOUNT NUMERIC(10,2) );
+			INSERT INTO T VALUES (1, 100);
+			INSERT INTO T VALUES (2, 200);
+			STASH BOOKS SELECT ID, AMOUNT FROM T;
+			DELETE FROM T WHERE ID = 2;
+			ATTACH CSV '` + filepath.Join(dir, "bank.csv") + `' AS BANK;
+			SPOOL ` + spool + `;
+			SCRATCH SELECT B.ID, B.AMOUNT, K.AMOUNT FROM BOOKS B LEFT JOIN BANK K ON B.ID = K.ID WHERE K.AMOUNT IS NULL OR K.AMOUNT <> B.AMOUNT ORDER BY B.ID;
+			SELECT COUNT(*) FROM BOOKS;
+			SPOOL OFF;
+			ROLLBACK;`,
+		"bank.csv": "ID,AMOUNT\n1,100\n",
+	})
+	// ATTACH on SQLite3 creates a temporary table of the connection, so
+	// another database is emulated with a copy of the dialect.
+	restoreColor := disableColor()
+	defer restoreColor()
+	info, err := dialect.ReadDBInfoFromArgs([]string{"sqlite3", ":memory:"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	other := *info.Dialect
+	cfg := New()
+	cfg.Script = filepath.Join(dir, "main.sql")
+	if err := cfg.Run(info.Driver, info.DataSource, &other); err != nil {
+		t.Fatal(err.Error())
+	}
+	output, err := os.ReadFile(spool)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	expect := "ID,AMOUNT,AMOUNT|2,200,␀|COUNT(*)|2"
+	if got := strings.Join(lines, "|"); !strings.HasSuffix(got, expect) {
+		t.Fatalf("expect %s, but %s", expect, got)
+	}
+}
+
+func TestStashRefusesTableOfConnection(t *testing.T) {
+	_, err := runSQLiteScriptError(t, `
+		SPOOL {{SPOOL}};
+		CREATE TABLE T ( ID INTEGER );
+		STASH T SELECT 1 AS ID;`)
+	if !errors.Is(err, ErrScratchNameInUse) {
+		t.Fatalf("expect %v, but %v", ErrScratchNameInUse, err)
+	}
+}
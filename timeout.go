@@ -0,0 +1,99 @@
+package sqlbless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTimeout = errors.New("expected a duration (e.g. 30s, 5m) or OFF")
+
+// parseTimeout accepts a duration of time.ParseDuration, a number of
+// seconds, or OFF.
+func parseTimeout(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "OFF") {
+		return 0, nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil && n >= 0 {
+		return time.Duration(n * float64(time.Second)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s: %w", s, ErrInvalidTimeout)
+	}
+	return d, nil
+}
+
+// untimedCommands wait for the user or contain other statements,
+// so the timeout is not applied to them as a whole.
+var untimedCommands = map[string]struct{}{
+	"ACCEPT": o,
+	"ACC":    o,
+	"DESC":   o,
+	"EDIT":   o,
+	"HOST":   o,
+	"START":  o,
+	`\D`:     o,
+}
+
+// timeoutContext returns the context for the statement cmd cancelled with
+// context.DeadlineExceeded as the cause when the timeout of the session
+// passes. The timer can be stopped with stopTimeout.
+func (ss *session) timeoutContext(ctx context.Context, cmd string) (context.Context, context.CancelFunc) {
+	if ss.timeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := untimedCommands[strings.ToUpper(cmd)]; ok || strings.HasPrefix(cmd, "@") {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(ss.timeout, func() { cancel(context.DeadlineExceeded) })
+	ss.deadline = timer
+	return ctx, func() {
+		timer.Stop()
+		if ss.deadline == timer {
+			ss.deadline = nil
+		}
+		cancel(context.Canceled)
+	}
+}
+
+// stopTimeout stops the timer of the statement running now. It is called
+// when the first row has been fetched, so that the rows are not closed by
+// the timeout while the user is looking at them in the viewer.
+func (ss *session) stopTimeout() {
+	if ss.deadline != nil {
+		ss.deadline.Stop()
+	}
+}
+
+// applyServerTimeout sends the timeout to the server when the dialect
+// supports it, so that the server also stops the statement.
+func (ss *session) applyServerTimeout(ctx context.Context) error {
+	if ss.Dialect.SQLForTimeout == nil {
+		return nil
+	}
+	query := ss.Dialect.SQLForTimeout(ss.timeout)
+	if query == "" {
+		return nil
+	}
+	var err error
+	if ss.tx != nil {
+		_, err = ss.tx.ExecContext(ctx, query)
+	} else {
+		_, err = ss.conn.ExecContext(ctx, query)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", query, err)
+	}
+	return nil
+}
+
+func (ss *session) setTimeout(ctx context.Context, d time.Duration) error {
+	ss.timeout = d
+	return ss.forEachConnection(func() error { return ss.applyServerTimeout(ctx) })
+}
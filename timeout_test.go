@@ -0,0 +1,84 @@
+package sqlbless
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	for _, c := range []struct {
+		source string
+		expect time.Duration
+	}{
+		{"", 0},
+		{"OFF", 0},
+		{"0", 0},
+		{"30", 30 * time.Second},
+		{"1.5", 1500 * time.Millisecond},
+		{"5m", 5 * time.Minute},
+		{"250ms", 250 * time.Millisecond},
+	} {
+		d, err := parseTimeout(c.source)
+		if err != nil {
+			t.Fatalf("%q: %s", c.source, err.Error())
+		}
+		if d != c.expect {
+			t.Fatalf("%q: expect %s, but %s", c.source, c.expect, d)
+		}
+	}
+	for _, source := range []string{"ON", "-1s", "10x"} {
+		if _, err := parseTimeout(source); err == nil {
+			t.Fatalf("%q: expect an error", source)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	records, err := runSQLiteScriptError(t, `
+		SPOOL {{SPOOL}};
+		SET TIMEOUT 100ms;
+		SELECT 'ALIVE';
+		WITH RECURSIVE N(I) AS (SELECT 1 UNION ALL SELECT I+1 FROM N)
+		SELECT COUNT(*) FROM N;
+		SPOOL OFF;
+	`)
+	if err == nil {
+		t.Fatal("expect the script to stop by the timeout")
+	}
+	var timedOut, alive bool
+	for _, r := range records {
+		if len(r) == 1 && strings.HasPrefix(r[0], "Cancelled: timeout") {
+			timedOut = true
+		}
+		if len(r) == 1 && r[0] == "ALIVE" {
+			alive = true
+		}
+	}
+	if !alive {
+		t.Fatalf("statements before the timeout were not executed: %#v", records)
+	}
+	if !timedOut {
+		t.Fatalf("timeout was not reported: %#v", records)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	ss := &session{timeout: 50 * time.Millisecond}
+	ctx, cancel := ss.timeoutContext(context.Background(), "SELECT")
+	defer cancel()
+	ss.stopTimeout()
+	time.Sleep(100 * time.Millisecond)
+	if ctx.Err() != nil {
+		t.Fatal("the viewer would be cancelled by the timeout")
+	}
+
+	ctx, cancel = ss.timeoutContext(context.Background(), "SELECT")
+	defer cancel()
+	<-ctx.Done()
+	if !errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		t.Fatalf("expect %v, but %v", context.DeadlineExceeded, context.Cause(ctx))
+	}
+}
@@ -0,0 +1,79 @@
+package sqlbless
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hymkor/sqlbless/rowstocsv"
+)
+
+func formatElapsed(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		ms/3600000, (ms/60000)%60, (ms/1000)%60, ms%1000)
+}
+
+// printElapsed writes the time elapsed since start to stdErr
+// when TIMING is ON.
+func (ss *session) printElapsed(label string, start time.Time) {
+	ss.printDuration(label, time.Since(start))
+}
+
+func (ss *session) printDuration(label string, d time.Duration) {
+	if ss.Timing {
+		fmt.Fprintf(ss.stdErr, "Elapsed (%s): %s\n", label, formatElapsed(d))
+	}
+}
+
+// timedRows counts the rows read through it and records
+// when the last row was read. The viewer may read the rows in its
+// goroutine, so count and end are guarded by mu.
+type timedRows struct {
+	rowstocsv.Source
+	mu     sync.Mutex
+	count  int64
+	end    time.Time
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newTimedRows(rows rowstocsv.Source) *timedRows {
+	return &timedRows{
+		Source: rows,
+		closed: make(chan struct{}),
+	}
+}
+
+func (r *timedRows) Next() bool {
+	ok := r.Source.Next()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ok {
+		r.count++
+	} else if r.end.IsZero() {
+		r.end = time.Now()
+	}
+	return ok
+}
+
+func (r *timedRows) Close() error {
+	err := r.Source.Close()
+	r.once.Do(func() {
+		r.mu.Lock()
+		if r.end.IsZero() {
+			r.end = time.Now()
+		}
+		r.mu.Unlock()
+		close(r.closed)
+	})
+	return err
+}
+
+// result returns the number of rows read so far and when the last row
+// was read. The time is zero while the rows are still being read.
+func (r *timedRows) result() (int64, time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count, r.end
+}
@@ -0,0 +1,47 @@
+package sqlbless
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatElapsed(t *testing.T) {
+	d := time.Hour + 2*time.Minute + 3*time.Second + 45*time.Millisecond
+	if s := formatElapsed(d); s != "01:02:03.045" {
+		t.Fatalf("expect 01:02:03.045, but %s", s)
+	}
+}
+
+func TestTiming(t *testing.T) {
+	records := runSQLiteScript(t, `
+		CREATE TABLE TESTTBL ( ID NUMERIC, NAME CHAR VARYING(20) );
+		INSERT INTO TESTTBL VALUES (10,'FOO');
+		INSERT INTO TESTTBL VALUES (20,'BAR');
+
+		SPOOL {{SPOOL}};
+		SET TIMING ON;
+		SELECT * FROM TESTTBL;
+		UPDATE TESTTBL SET NAME = 'BAZ' WHERE ID = 10;
+		SET TIMING OFF;
+		SPOOL OFF;
+		ROLLBACK;
+	`)
+	var found []string
+	for _, r := range records {
+		if len(r) == 1 && (strings.HasPrefix(r[0], "Elapsed (") || strings.HasSuffix(r[0], "rows selected.")) {
+			title, _, _ := strings.Cut(r[0], ":")
+			found = append(found, title)
+		}
+	}
+	expect := []string{
+		"2 rows selected.",
+		"Elapsed (execute)",
+		"Elapsed (first row)",
+		"Elapsed (fetch)",
+		"Elapsed (execute)",
+	}
+	if strings.Join(found, "|") != strings.Join(expect, "|") {
+		t.Fatalf("expect %#v, but %#v", expect, found)
+	}
+}
@@ -0,0 +1,228 @@
+package sqlbless
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hymkor/sqlbless/internal/misc"
+)
+
+// Exit codes of the process
+const (
+	ExitSuccess         = 0
+	ExitFailure         = 1 // invalid options, script files not found and so on
+	ExitSQLError        = 2 // a statement failed
+	ExitConnectionError = 3 // failed to connect or lost the connection
+	ExitUncommitted     = 4 // the transaction was not committed at the end of the script
+)
+
+var (
+	ErrInvalidWheneverSyntax = errors.New("invalid syntax: expected 'WHENEVER SQLERROR {EXIT [SUCCESS|FAILURE|code] [COMMIT|ROLLBACK] | CONTINUE [NONE|COMMIT|ROLLBACK]}'")
+	ErrInvalidExitSyntax     = errors.New("invalid syntax: expected 'EXIT [SUCCESS|FAILURE|code] [COMMIT|ROLLBACK]'")
+	ErrUncommittedAtEnd      = errors.New("the transaction was not committed at the end of the script, so it was rolled back")
+)
+
+// ExitError ends the session with the exit code Code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the process for the error returned by Run.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	if isConnectionError(err) {
+		return ExitConnectionError
+	}
+	return ExitFailure
+}
+
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.As(err, &netErr)
+}
+
+func errorExitCode(err error) int {
+	if isConnectionError(err) {
+		return ExitConnectionError
+	}
+	return ExitSQLError
+}
+
+// sqlErrorAction is the action set by WHENEVER SQLERROR.
+type sqlErrorAction struct {
+	exit bool
+	code int // negative means the code for the class of the error
+	tcl  string
+}
+
+func parseExitCode(s string) (int, bool) {
+	switch strings.ToUpper(s) {
+	case "SUCCESS":
+		return ExitSuccess, true
+	case "FAILURE":
+		return ExitFailure, true
+	}
+	code, err := strconv.Atoi(s)
+	return code, err == nil && code >= 0
+}
+
+// parseExitArgs parses `[SUCCESS|FAILURE|code] [COMMIT|ROLLBACK]`.
+func parseExitArgs(arg string, code int) (int, string, bool) {
+	tcl := ""
+	word, arg := misc.CutField(arg)
+	if word != "" {
+		if c, ok := parseExitCode(word); ok {
+			code = c
+			word, arg = misc.CutField(arg)
+		}
+	}
+	if word != "" {
+		switch upper := strings.ToUpper(word); upper {
+		case "COMMIT", "ROLLBACK":
+			tcl = upper
+		default:
+			return 0, "", false
+		}
+		word, _ = misc.CutField(arg)
+	}
+	return code, tcl, word == ""
+}
+
+func doWhenever(ss *session, arg string) error {
+	condition, arg := misc.CutField(arg)
+	if !strings.EqualFold(condition, "SQLERROR") {
+		return ErrInvalidWheneverSyntax
+	}
+	action, arg := misc.CutField(arg)
+	switch strings.ToUpper(action) {
+	case "EXIT":
+		code, tcl, ok := parseExitArgs(arg, -1)
+		if !ok {
+			return ErrInvalidWheneverSyntax
+		}
+		ss.whenever = &sqlErrorAction{exit: true, code: code, tcl: tcl}
+	case "CONTINUE":
+		tcl, rest := misc.CutField(arg)
+		tcl = strings.ToUpper(tcl)
+		if tcl == "NONE" {
+			tcl = ""
+		}
+		if (tcl != "" && tcl != "COMMIT" && tcl != "ROLLBACK") || rest != "" {
+			return ErrInvalidWheneverSyntax
+		}
+		ss.whenever = &sqlErrorAction{tcl: tcl}
+	default:
+		return ErrInvalidWheneverSyntax
+	}
+	fmt.Fprintln(ss.stdErr, "Ok")
+	return nil
+}
+
+func (ss *session) closeTransaction(tcl string) error {
+	if ss.tx == nil {
+		return nil
+	}
+	switch tcl {
+	case "COMMIT":
+		return ss.commit()
+	case "ROLLBACK":
+		return ss.rollback()
+	}
+	return nil
+}
+
+// onError decides whether the session continues after err. sqlError is
+// false for the errors of the commands of SQL-Bless such as invalid syntax
+// and missing files, which do not trigger WHENEVER SQLERROR but end the
+// scripts as without it.
+// It returns nil to continue, otherwise the error to end the session.
+func (ss *session) onError(err error, sqlError bool, commandIn commandIn) error {
+	action := ss.whenever
+	if action == nil || !sqlError {
+		if commandIn.OnErrorAbort() {
+			var exitErr *ExitError
+			if errors.As(err, &exitErr) {
+				return err
+			}
+			if !sqlError {
+				return &ExitError{Code: ExitFailure, Err: err}
+			}
+			return &ExitError{Code: errorExitCode(err), Err: err}
+		}
+		return nil
+	}
+	if err := ss.closeTransaction(action.tcl); err != nil {
+		fmt.Fprintln(ss.stdErr, err.Error())
+	}
+	if !action.exit {
+		return nil
+	}
+	code := action.code
+	if code < 0 {
+		code = errorExitCode(err)
+	}
+	ss.exiting = true
+	return &ExitError{Code: code, Err: err}
+}
+
+// doExit ends the session. Without COMMIT or ROLLBACK, the interactive
+// session can not be ended while the transaction is open.
+func doExit(ss *session, arg string, commandIn commandIn) error {
+	code, tcl, ok := parseExitArgs(arg, ExitSuccess)
+	if !ok {
+		return ErrInvalidExitSyntax
+	}
+	if names := ss.openTransactions(); tcl == "" && len(names) > 0 && !commandIn.CanCloseInTransaction() {
+		return ss.errTransactionsNotClosed(names)
+	}
+	if err := ss.forEachConnection(func() error { return ss.closeTransaction(tcl) }); err != nil {
+		return err
+	}
+	ss.exiting = true
+	return &ExitError{Code: code}
+}
+
+// exitStatus converts the error ending the session to the one returned
+// by Config.Run. A transaction left open at the end of the script is
+// rolled back and reported.
+func (ss *session) exitStatus(err error) error {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) && exitErr.Code == ExitSuccess && exitErr.Err == nil {
+		return nil
+	}
+	if err == nil && len(ss.openTransactions()) > 0 {
+		ss.forEachConnection(func() error { return ss.closeTransaction("ROLLBACK") })
+		fmt.Fprintln(ss.stdErr, ErrUncommittedAtEnd.Error())
+		return &ExitError{Code: ExitUncommitted, Err: ErrUncommittedAtEnd}
+	}
+	return err
+}
+
+func doPrompt(ss *session, arg string) {
+	fmt.Fprintln(ss.stdOut, strings.TrimLeft(arg, " \t"))
+}
@@ -0,0 +1,143 @@
+package sqlbless
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func spooledLines(records [][]string) string {
+	var lines []string
+	for _, r := range records {
+		lines = append(lines, strings.Join(r, ","))
+	}
+	return "|" + strings.Join(lines, "|") + "|"
+}
+
+func TestWheneverSqlError(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		script string
+		code   int
+		alive  bool
+	}{
+		{
+			name:  "default",
+			code:  ExitSQLError,
+			alive: false,
+			script: `
+				SELECT * FROM NOT_EXISTS;`,
+		},
+		{
+			name:  "continue",
+			code:  ExitSuccess,
+			alive: true,
+			script: `
+				WHENEVER SQLERROR CONTINUE;
+				SELECT * FROM NOT_EXISTS;`,
+		},
+		{
+			name:  "exit with code",
+			code:  7,
+			alive: false,
+			script: `
+				WHENEVER SQLERROR EXIT 7 ROLLBACK;
+				SELECT * FROM NOT_EXISTS;`,
+		},
+		{
+			name:  "client error",
+			code:  ExitFailure,
+			alive: false,
+			script: `
+				WHENEVER SQLERROR EXIT 7;
+				EXIT NOW;`,
+		},
+		{
+			name:  "client error with continue",
+			code:  ExitFailure,
+			alive: false,
+			script: `
+				WHENEVER SQLERROR CONTINUE;
+				START not_exists.sql;`,
+		},
+		{
+			name:  "client error without whenever",
+			code:  ExitFailure,
+			alive: false,
+			script: `
+				EXIT NOW;`,
+		},
+		{
+			name:  "exit failure",
+			code:  ExitFailure,
+			alive: false,
+			script: `
+				WHENEVER SQLERROR EXIT FAILURE;
+				SELECT * FROM NOT_EXISTS;`,
+		},
+	} {
+		records, err := runSQLiteScriptError(t, `
+			SPOOL {{SPOOL}};
+			`+c.script+`
+			SELECT 'ALIVE';
+			SPOOL OFF;`)
+		if code := ExitCode(err); code != c.code {
+			t.Fatalf("%s: expect exit code %d, but %d (%v)", c.name, c.code, code, err)
+		}
+		if alive := strings.Contains(spooledLines(records), "|ALIVE|"); alive != c.alive {
+			t.Fatalf("%s: expect alive=%v, but %v", c.name, c.alive, alive)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	_, err := runSQLiteScriptError(t, `
+		SPOOL {{SPOOL}};
+		CREATE TABLE T ( ID NUMERIC );
+		INSERT INTO T VALUES (1);
+		SPOOL OFF;`)
+	if !errors.Is(err, ErrUncommittedAtEnd) || ExitCode(err) != ExitUncommitted {
+		t.Fatalf("expect ErrUncommittedAtEnd, but %v", err)
+	}
+
+	_, err = runSQLiteScriptError(t, `
+		SPOOL {{SPOOL}};
+		INSERT INTO T VALUES (1);
+		SPOOL OFF;
+		EXIT;`)
+	if code := ExitCode(err); code != ExitSQLError {
+		t.Fatalf("expect %d, but %d (%v)", ExitSQLError, code, err)
+	}
+
+	_, err = runSQLiteScriptError(t, `
+		SPOOL {{SPOOL}};
+		CREATE TABLE T ( ID NUMERIC );
+		INSERT INTO T VALUES (1);
+		SPOOL OFF;
+		EXIT 5 COMMIT;
+		SELECT 'NOT REACHED';`)
+	if code := ExitCode(err); code != 5 {
+		t.Fatalf("expect 5, but %d (%v)", code, err)
+	}
+
+	_, err = runSQLiteScriptError(t, `
+		SPOOL {{SPOOL}};
+		SPOOL OFF;
+		EXIT;
+		SELECT * FROM NOT_EXISTS;`)
+	if err != nil {
+		t.Fatalf("expect nil, but %v", err)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	records := runSQLiteScript(t, `
+		SPOOL {{SPOOL}};
+		SET ECHO OFF;
+		DEFINE WHO = World;
+		PROMPT Hello, &WHO.!;
+		SPOOL OFF;`)
+	if !strings.Contains(spooledLines(records), "|Hello, World!|") {
+		t.Fatalf("PROMPT was not spooled: %#v", records)
+	}
+}